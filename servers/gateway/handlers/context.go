@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"questionqueue/servers/gateway/store"
+	"strings"
 
 	"github.com/streadway/amqp"
 
@@ -57,9 +59,10 @@ func (ctx *HandlerContext) WebSocketConnectionHandler(w http.ResponseWriter, r *
 	}
 
 	identification := r.URL.Query().Get("identification")
-	if identification != "" {
+	classes := parseClasses(r.URL.Query().Get("class"))
+	if identification != "" && len(classes) > 0 {
 		// insert connection to list
-		ctx.Notifier.InsertConnection(conn, identification, isTeacher)
+		ctx.Notifier.InsertConnection(conn, identification, isTeacher, classes)
 
 		// For each new websocket connection, start a goroutine to handler connection defer
 		go (func(conn *websocket.Conn, ctx *HandlerContext, identification string) {
@@ -69,17 +72,21 @@ func (ctx *HandlerContext) WebSocketConnectionHandler(w http.ResponseWriter, r *
 				messageType, p, err := conn.ReadMessage()
 				if messageType == websocket.TextMessage || messageType == websocket.BinaryMessage {
 					fmt.Print("Client says", p)
-					err := ctx.Channel.Publish(
-						"",
-						"queue",
-						false,
-						false,
-						amqp.Publishing{
-							ContentType: "text/plain",
-							Body:        []byte("a"),
-						})
-					if err != nil {
-						log.Printf("Failed to publish ws")
+					// ask for a refresh of every class queue the client subscribed to
+					for class := range classes {
+						body, _ := json.Marshal(map[string]string{"type": "queue-refresh", "class": class})
+						err := ctx.Channel.Publish(
+							"",
+							"queue",
+							false,
+							false,
+							amqp.Publishing{
+								ContentType: "text/plain",
+								Body:        body,
+							})
+						if err != nil {
+							log.Printf("Failed to publish ws")
+						}
 					}
 
 				} else if messageType == websocket.CloseMessage || err != nil {
@@ -90,3 +97,15 @@ func (ctx *HandlerContext) WebSocketConnectionHandler(w http.ResponseWriter, r *
 	}
 
 }
+
+// parseClasses takes the comma separated class codes a client subscribes to
+// and returns them as a set
+func parseClasses(param string) map[string]bool {
+	classes := make(map[string]bool)
+	for _, class := range strings.Split(param, ",") {
+		if class = strings.TrimSpace(class); len(class) > 0 {
+			classes[class] = true
+		}
+	}
+	return classes
+}
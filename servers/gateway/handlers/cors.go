@@ -13,7 +13,7 @@ const accessControlExposedHeaders = "Access-Control-Expose-Headers"
 const accessControlMaxAge = "Access-Control-Max-Age"
 
 const allowedMethods = "GET, PUT, POST, PATCH, DELETE"
-const allowedHeaders = "Content-Type, Authorization"
+const allowedHeaders = "Content-Type, Authorization, X-Question-Token"
 const exposedHeaders = "Authorization"
 const maxAge = "600"
 
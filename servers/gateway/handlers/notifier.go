@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"questionqueue/servers/gateway/store"
+	"questionqueue/src/model"
 	"sync"
 
 	"github.com/streadway/amqp"
@@ -18,21 +19,31 @@ type Notifier struct {
 	lock        sync.Mutex
 }
 
-// QueueConnection is a struct that will keep track of the connection
-// and if the user connected is a teacher or not
+// QueueConnection is a struct that will keep track of the connection,
+// if the user connected is a teacher or not and which class queues
+// the user subscribed to
 type QueueConnection struct {
 	IsTeacher  bool
 	Connection *websocket.Conn
+	Classes    map[string]bool
+}
+
+// queueMessage is the part of a message from the queue that the gateway
+// needs to know which class queue has been updated
+type queueMessage struct {
+	Type  string `json:"type"`
+	Class string `json:"class"`
 }
 
 // InsertConnection will insert the websocket connection based on the provided identification
 // which Teachers will provide as well during the websocket connection.
-func (n *Notifier) InsertConnection(conn *websocket.Conn, id string, isTeacher bool) {
+func (n *Notifier) InsertConnection(conn *websocket.Conn, id string, isTeacher bool, classes map[string]bool) {
 	n.lock.Lock()
 	defer n.lock.Unlock()
 	newConnection := &QueueConnection{
 		isTeacher,
 		conn,
+		classes,
 	}
 	if len(n.Connections) == 0 {
 		n.Connections = make(map[string]*QueueConnection)
@@ -50,14 +61,26 @@ func (n *Notifier) RemoveConnection(id string) {
 // SendMessagesToWebsockets is
 func (n *Notifier) SendMessagesToWebsockets(messages <-chan amqp.Delivery, sessAndQueueStore store.Store) {
 	for message := range messages {
+		// For any received message, we immediately know it is because the queue of
+		// the class in the message has been updated.
+		qm := &queueMessage{}
+		if err := json.Unmarshal(message.Body, qm); err != nil || len(qm.Class) == 0 {
+			log.Printf("Error reading the class of message %v: %v", string(message.Body), err)
+			message.Ack(false)
+			continue
+		}
+
 		n.lock.Lock()
-		// For any received message, we immediately know it is because the queue has been updated.
-		// First,we grab the current queue from redis
-		currQueue, err := sessAndQueueStore.GetCurrentQueue()
+		// First,we grab the current queue of the class from redis
+		currQueue, err := sessAndQueueStore.GetCurrentQueue(qm.Class)
 		if err != nil {
-			log.Printf("Error getting the current queue: %v", err)
+			log.Printf("Error getting the current queue of %v: %v", qm.Class, err)
+			message.Ack(false)
+			n.lock.Unlock()
+			continue
 		}
-		// get studentized queue and marshal both regular queue and student queue positions
+		// tell clients what changed and get studentized queue and marshal both regular queue and student queue positions
+		currQueue.Type = qm.Type
 		studentPositions := currQueue.GetStudentPositions()
 		queueMarshalled, err := json.Marshal(currQueue)
 		if err != nil {
@@ -66,21 +89,30 @@ func (n *Notifier) SendMessagesToWebsockets(messages <-chan amqp.Delivery, sessA
 
 		log.Printf("queueMarshalled: %v", string(queueMarshalled))
 
-		// Notify all the users of a new queue state
+		// Notify all the users subscribed to the class of a new queue state
 		for id, conn := range n.Connections {
+			if !conn.Classes[qm.Class] {
+				continue
+			}
+
 			if conn.IsTeacher {
 				if err := conn.Connection.WriteMessage(websocket.TextMessage, queueMarshalled); err != nil {
-					n.RemoveConnection(id)
+					delete(n.Connections, id)
 					conn.Connection.Close()
 				}
 
 			} else {
-				studentPositionedMarshalled, err := json.Marshal(studentPositions[id])
+				position, ok := studentPositions[id]
+				if !ok {
+					// students who are not in line still learn whether they can join
+					position = &store.PositionInLine{Type: currQueue.Type, PositionInLine: &model.PositionInLine{Class: currQueue.Class, QueueLength: len(currQueue.Queue), State: currQueue.State}}
+				}
+				studentPositionedMarshalled, err := json.Marshal(position)
 				if err != nil {
 					log.Printf("Error marshalling student position for %v: %v", id, err)
 				}
 				if err := conn.Connection.WriteMessage(websocket.TextMessage, studentPositionedMarshalled); err != nil {
-					n.RemoveConnection(id)
+					delete(n.Connections, id)
 					conn.Connection.Close()
 				}
 			}
@@ -139,9 +139,38 @@ func main() {
 	// rw
 	mux.Handle("/v1/student", rwProxy)
 	mux.Handle("/v1/teacher", rwProxy)
+	mux.Handle("/v1/teacher/sessions", rwProxy)
+	mux.Handle("/v1/teacher/sessions/{session_id}", rwProxy)
+	mux.Handle("/v1/teacher/password", rwProxy)
+	mux.Handle("/v1/teacher/verify", rwProxy)
 	mux.Handle("/v1/teacher/{teacher_id}", rwProxy)
+	mux.Handle("/v1/teacher/{teacher_id}/role", rwProxy)
 	mux.Handle("/v1/teacher/login", rwProxy)
 	mux.Handle("/v1/student/{student_id}", rwProxy)
+	mux.Handle("/v1/queue/{class}", rwProxy)
+	mux.Handle("/v1/queue/{class}/history", rwProxy)
+	mux.Handle("/v1/queue/{class}/fairness", rwProxy)
+	mux.Handle("/v1/queue/{class}/noshows", rwProxy)
+	mux.Handle("/v1/queue/{class}/state", rwProxy)
+	mux.Handle("/v1/queue/{class}/policy", rwProxy)
+	mux.Handle("/v1/queue/{class}/duty", rwProxy)
+	mux.Handle("/v1/queue/{class}/dispatch", rwProxy)
+	mux.Handle("/v1/queue/{class}/next", rwProxy)
+	mux.Handle("/v1/queue/{class}/skips", rwProxy)
+	mux.Handle("/v1/queue/{class}/groups", rwProxy)
+	mux.Handle("/v1/queue/{class}/groups/{group_id}/{action}", rwProxy)
+	mux.Handle("/v1/queue/{class}/join", rwProxy)
+	mux.Handle("/v1/queue/{class}/join/qr", rwProxy)
+	mux.Handle("/v1/queue/{class}/{student_id}/{action}", rwProxy)
+	mux.Handle("/v1/staff/{class}", rwProxy)
+	mux.Handle("/v1/staff/{class}/{teacher_id}", rwProxy)
+	mux.Handle("/v1/invite", rwProxy)
+	mux.Handle("/v1/invite/{invite_id}", rwProxy)
+	mux.Handle("/v1/schedule/{class}", rwProxy)
+	mux.Handle("/v1/schedule/{class}/next", rwProxy)
+	mux.Handle("/v1/lab/{class}", rwProxy)
+	mux.Handle("/v1/lab/{class}/close", rwProxy)
+	mux.Handle("/v1/admin/reconcile", rwProxy)
 	//aj
 	mux.Handle("/v1/class", ajProxy)
 	mux.Handle("/v1/class/{class_number}", ajProxy)
@@ -1,42 +1,43 @@
 package store
 
-import "time"
-
-// Question is used for individual questions
-type Question struct {
-	ID        string    `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Class     string    `json:"class,omitempty"`
-	Topic     string    `json:"topic,omitempty"`
-	Problem   string    `json:"description,omitempty"`
-	LocationX float64   `json:"loc_x,omitempty"`
-	LocationY float64   `json:"loc_y,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
-}
+import "questionqueue/src/model"
 
 // QuestionQueue will be unmarshalled from the redis store
 // this requires the json the queue receives to be in the format:
 // {
+//		"type": "type of the last change",
+//		"class": "class_code",
 //		"queue": [
 // 			{ format of question struct }
+//		],
+//		"helping": [
+// 			{ format of question struct }
+//		],
+//		"wait": { format of wait stats struct },
+//		"state": { format of queue state struct },
+//		"policy": { format of policy struct },
+//		"moves": [
+//			{ format of policy move struct }
 //		]
 // }
 type QuestionQueue struct {
-	Queue []*Question `json:"queue"`
+	Type string `json:"type,omitempty"`
+	*model.QuestionQueue
 }
 
-// PositionInLine is the position in line for the student map
+// PositionInLine is the position in line of a student, as the rw service computes it,
+// along with the type of the last change
 type PositionInLine struct {
-	Position    int `json:"position"`
-	QueueLength int `json:"queueLength"`
+	Type string `json:"type,omitempty"`
+	*model.PositionInLine
 }
 
 // GetStudentPositions will convert the entire queue into a map to get
-// student positions faster.
+// student positions faster, estimating their wait the same way the rw service does.
 func (q *QuestionQueue) GetStudentPositions() map[string]*PositionInLine {
 	studentPositions := make(map[string]*PositionInLine)
-	for i, question := range q.Queue {
-		studentPositions[question.ID] = &PositionInLine{i + 1, len(q.Queue)}
+	for id, position := range q.QuestionQueue.GetStudentPositions() {
+		studentPositions[id] = &PositionInLine{q.Type, position}
 	}
 	return studentPositions
 }
@@ -2,8 +2,10 @@ package store
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/go-redis/redis"
+	"questionqueue/src/model"
 )
 
 //RedisStore represents a session.Store backed by redis.
@@ -18,18 +20,61 @@ func NewRedisStore(client *redis.Client, redisQueueName string) *RedisStore {
 	return &RedisStore{client, redisQueueName}
 }
 
-// GetCurrentQueue gets the current queue from redis
-func (s *RedisStore) GetCurrentQueue() (*QuestionQueue, error) {
-	returnQueue := &QuestionQueue{}
-	getQueue := s.Client.Get(s.redisQueueName)
-	if getQueue.Err() != nil {
-		if getQueue.Err().Error() == "redis: nil" {
-			return returnQueue, nil
+// GetCurrentQueue gets the current queue of a given class code from redis.
+// The line is a sorted set of student IDs next to a hash of their questions;
+// both are read in one MULTI/EXEC, along with the wait stats, the state and the ordering
+// policy of the class, so they belong to the same queue state. The line is ordered by the policy
+// the same way the rw service orders it, see `model.NewQuestionQueue`.
+func (s *RedisStore) GetCurrentQueue(class string) (*QuestionQueue, error) {
+	var ids *redis.StringSliceCmd
+	var questions *redis.StringStringMapCmd
+	var wait, state, policy *redis.StringCmd
+	_, err := s.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		ids = pipe.ZRange(s.queueKey(class), 0, -1)
+		questions = pipe.HGetAll(s.queueKey(class) + ":questions")
+		wait = pipe.Get(s.queueKey(class) + ":wait")
+		state = pipe.Get(s.queueKey(class) + ":state")
+		policy = pipe.Get(s.queueKey(class) + ":policy")
+		return nil
+	})
+	// a class without wait stats, state or policy yet has no such keys
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	// a class that never had a policy set is FIFO
+	linePolicy := model.NewPolicy(class)
+	if j, err := policy.Result(); err == nil {
+		if unmarshallErr := json.Unmarshal([]byte(j), linePolicy); unmarshallErr != nil {
+			return nil, unmarshallErr
+		}
+	}
+
+	var line []*model.Question
+	for _, id := range ids.Val() {
+		j, ok := questions.Val()[id]
+		if !ok {
+			continue
+		}
+		q := &model.Question{}
+		if unmarshallErr := json.Unmarshal([]byte(j), q); unmarshallErr != nil {
+			return nil, unmarshallErr
 		}
-		return nil, getQueue.Err()
+		line = append(line, q)
 	}
-	if unmarshallErr := json.Unmarshal([]byte(getQueue.Val()), returnQueue); unmarshallErr != nil {
-		return nil, unmarshallErr
+	returnQueue := &QuestionQueue{QuestionQueue: model.NewQuestionQueue(class, line, linePolicy, time.Now())}
+
+	if j, err := wait.Result(); err == nil {
+		returnQueue.Wait = &model.WaitStats{}
+		if unmarshallErr := json.Unmarshal([]byte(j), returnQueue.Wait); unmarshallErr != nil {
+			return nil, unmarshallErr
+		}
+	}
+	if j, err := state.Result(); err == nil {
+		returnQueue.State = &model.QueueState{}
+		if unmarshallErr := json.Unmarshal([]byte(j), returnQueue.State); unmarshallErr != nil {
+			return nil, unmarshallErr
+		}
 	}
 	return returnQueue, nil
 }
@@ -45,3 +90,9 @@ func (s *RedisStore) IsFoundSessionID(bearerToken string) bool {
 
 	return getSessionID.Val() != ""
 }
+
+// queueKey returns the redis key of the queue of a given class code,
+// using the redis queue name as the prefix
+func (s *RedisStore) queueKey(class string) string {
+	return s.redisQueueName + ":" + class
+}
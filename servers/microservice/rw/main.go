@@ -7,8 +7,11 @@ import (
 	"os"
 	"questionqueue/src/db"
 	"questionqueue/src/handler"
+	"questionqueue/src/mail"
+	"questionqueue/src/model"
 	"questionqueue/src/notifier"
 	"questionqueue/src/session"
+	"strings"
 	"time"
 )
 
@@ -29,6 +32,46 @@ func main() {
 	sessionKey := os.Getenv("SESSIONKEY")
 	if len(sessionKey) == 0 { sessionKey = "default_key" }
 
+	// keys session IDs were signed with before the current one, accepted until their time,
+	// as `<key>@<RFC 3339 time>,...`; rotate the key by moving it here along with the end of its grace period
+	retiredKeys, err := session.ParseRetiredKeys(os.Getenv("OLDSESSIONKEYS"))
+	if err != nil {
+		log.Fatalf("cannot parse OLDSESSIONKEYS: %v", err)
+	}
+
+	// emails of the TA/teachers who are always admins, comma separated
+	var adminEmails []string
+	for _, email := range strings.Split(os.Getenv("ADMINEMAILS"), ",") {
+		if email = strings.TrimSpace(email); len(email) != 0 {
+			adminEmails = append(adminEmails, email)
+		}
+	}
+
+	// the client page students join a queue from, which join links and their QR codes point to
+	joinURL := os.Getenv("JOINURL")
+	if len(joinURL) == 0 { joinURL = "http://localhost:3000/join" }
+
+	// the client pages TA/teachers reset their password and verify their email from, which mailed links point to
+	resetURL := os.Getenv("RESETURL")
+	if len(resetURL) == 0 { resetURL = "http://localhost:3000/reset" }
+
+	verifyURL := os.Getenv("VERIFYURL")
+	if len(verifyURL) == 0 { verifyURL = "http://localhost:3000/verify" }
+
+	// emails go out through the SMTP server at SMTPADDR, or are appended to MAILFILE without one
+	mailFrom := os.Getenv("MAILFROM")
+	if len(mailFrom) == 0 { mailFrom = "QuestionQueue <noreply@localhost>" }
+
+	var mailer mail.Sender
+	if smtpAddr := os.Getenv("SMTPADDR"); len(smtpAddr) != 0 {
+		mailer = mail.NewSMTPSender(smtpAddr, mailFrom, os.Getenv("SMTPUSER"), os.Getenv("SMTPPASS"))
+	} else {
+		mailFile := os.Getenv("MAILFILE")
+		if len(mailFile) == 0 { mailFile = "mail.log" }
+		log.Printf("no SMTPADDR, appending emails to %v", mailFile)
+		mailer = mail.NewFileSender(mailFile, mailFrom)
+	}
+
 	log.Println("mongoAddr:",mongoAddr)
 	ms, err := db.NewMongoStore(mongoAddr)
 	if err != nil {
@@ -56,13 +99,51 @@ func main() {
 	n := notifier.NewNotifier(ch, q)
 
 	ctx := handler.Context{
-		Key:          sessionKey,
+		Keys:         session.NewSigningKeys(sessionKey, retiredKeys...),
 		SessionStore: redis,
 		MongoStore:   ms,
 		Trie:         nil,
 		Notifier:     n,
+		JoinURL:      joinURL,
+		AdminEmails:  adminEmails,
+		Mailer:       mailer,
+		ResetURL:     resetURL,
+		VerifyURL:    verifyURL,
+	}
+
+	ctx.EnsureAdmins()
+
+	// questions asked before questions had a status left their queue long ago; they must not be put back in line
+	if archived, err := ms.ArchiveStatuslessQuestions(time.Now()); err != nil {
+		log.Fatalf("cannot archive questions without a status: %v", err)
+	} else if archived > 0 {
+		log.Printf("archived %v questions without a status", archived)
 	}
 
+	// the events are numbered by a counter in redis; it must go on from the event log if redis lost it
+	seq, err := ms.GetLastEventSeq()
+	if err != nil {
+		log.Fatalf("cannot read the last event: %v", err)
+	}
+	if err := redis.SeedEventSeq(seq); err != nil {
+		log.Fatalf("cannot seed the event counter: %v", err)
+	}
+
+	// Redis may have lost the queues, e.g. when it restarted without persistence;
+	// put every unresolved question back in line before taking requests
+	reconciliations, err := ctx.Reconcile("")
+	if err != nil {
+		log.Printf("cannot reconcile queues: %v", err)
+	}
+	for _, r := range reconciliations {
+		log.Printf("reconciled queue %v: %v restored, %v unknown to mongo", r.Class, len(r.Restored), len(r.Unknown))
+	}
+
+	// open and close the queues at the start and end of the meetings in their schedules
+	go ctx.RunSchedules(time.Minute)
+	// log the events of the changes to the queues that mongo did not take right away
+	go ctx.FlushEvents(10 * time.Second)
+
 	router := mux.NewRouter()
 
 	// test connection
@@ -71,18 +152,81 @@ func main() {
 	router.HandleFunc("/v1/teacher", ctx.TeacherHandler)
 	// TA/teacher session control: POST, DELETE
 	router.HandleFunc("/v1/teacher/login", ctx.TeacherSessionHandler)
+	// TA/teacher sessions control - GET the active sessions of a TA/teacher; sign out everywhere: GET, DELETE
+	router.HandleFunc("/v1/teacher/sessions", ctx.TeacherSessionsHandler)
+	// Specific TA/teacher session control - sign out one device: DELETE
+	router.HandleFunc("/v1/teacher/sessions/{session_id}", ctx.SpecificTeacherSessionHandler)
+	// TA/teacher password reset - send a reset link; reset the password with its token: POST, PUT
+	router.HandleFunc("/v1/teacher/password", ctx.PasswordResetHandler)
+	// TA/teacher email verification - send a verify-email link; verify the email with its token: POST, PUT
+	router.HandleFunc("/v1/teacher/verify", ctx.EmailVerificationHandler)
+	// TA/teacher role control - change the role of the account of a TA/teacher, for admins: PUT
+	router.HandleFunc("/v1/teacher/{id}/role", ctx.RequireRole(model.RoleAdmin, ctx.TeacherRoleHandler))
 	// Specific TA/teacher control: GET
 	// only accepts `me` or `all`
 	router.HandleFunc("/v1/teacher/{id}", ctx.TeacherProfileHandler)
 	// Question control - POSTing new questions and enqueue: POST
 	router.HandleFunc("/v1/student", ctx.PostQuestionHandler)
-	// Question control - DELETE dequeues an existing question: DELETE
-	router.HandleFunc("/v1/student/{id}", ctx.DeleteQuestionHandler)
+	// Specific question control - GET position in line; DELETE dequeues an existing question: GET, DELETE
+	// requires the class code of the queue as query parameter `class`
+	router.HandleFunc("/v1/student/{id}", ctx.SpecificQuestionHandler)
+	// Queue control - GET the entire queue of a class: GET
+	router.HandleFunc("/v1/queue/{class}", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.QueueHandler))
+	// Queue history control - GET the queue of a class at a point in time: GET
+	router.HandleFunc("/v1/queue/{class}/history", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.QueueHistoryHandler))
+	// Fairness audit - GET every student helped while someone who lined up earlier was waiting: GET
+	router.HandleFunc("/v1/queue/{class}/fairness", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.FairnessHandler))
+	// Queue state control - GET whether students can join; open, pause or close, limit or set a last call: GET, PUT
+	router.HandleFunc("/v1/queue/{class}/state", ctx.RequireClassRole("", model.RoleTA, ctx.QueueStateHandler))
+	// Queue policy control - GET how the line of a class is ordered; change it: GET, PUT
+	router.HandleFunc("/v1/queue/{class}/policy", ctx.RequireClassRole(model.RoleTA, model.RoleInstructor, ctx.PolicyHandler))
+	// Duty control - GET the TA/teachers on duty in a class; go on duty covering some topics, or off duty: GET, PUT, DELETE
+	router.HandleFunc("/v1/queue/{class}/duty", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.DutyHandler))
+	// Dispatch control - GET how students are handed out to TA/teachers; change it: GET, PUT
+	router.HandleFunc("/v1/queue/{class}/dispatch", ctx.RequireClassRole(model.RoleTA, model.RoleInstructor, ctx.DispatchHandler))
+	// Dispatch control - claim the student a TA/teacher should help next: POST
+	router.HandleFunc("/v1/queue/{class}/next", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.NextQuestionHandler))
+	// Dispatch audit - GET the students passed over by a TA/teacher helping someone behind them: GET
+	router.HandleFunc("/v1/queue/{class}/skips", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.SkipHandler))
+	// Group control - GET groups of waiting students on the same topic; claim students as a group: GET, POST
+	router.HandleFunc("/v1/queue/{class}/groups", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.GroupHandler))
+	// Group control - start helping, resolve or release every student of a group: POST
+	router.HandleFunc("/v1/queue/{class}/groups/{group_id}/{action}", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.GroupActionHandler))
+	// Join code control - GET the current join code and link of a class; change how often it rotates: GET, PUT
+	router.HandleFunc("/v1/queue/{class}/join", ctx.RequireClassRole(model.RoleTA, model.RoleInstructor, ctx.JoinCodeHandler))
+	// Join code control - GET a PNG QR code of the current join link of a class: GET
+	router.HandleFunc("/v1/queue/{class}/join/qr", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.JoinQRHandler))
+	// No-show control - GET how many times every student of a class was not there: GET
+	router.HandleFunc("/v1/queue/{class}/noshows", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.NoShowHandler))
+	// Question reorder control - move a question to a position, requeue it at the back or hold it: POST
+	router.HandleFunc("/v1/queue/{class}/{id}/{action:move|requeue|hold}", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.QuestionReorderHandler))
+	// Question status control - claim, release, start, resolve, noshow or withdraw a question: POST
+	router.HandleFunc("/v1/queue/{class}/{id}/{action}", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.QuestionStatusHandler))
+	// Staff control - GET the staff of a class; add a TA/teacher to it as a TA or an instructor: GET, POST
+	router.HandleFunc("/v1/staff/{class}", ctx.RequireClassRole(model.RoleTA, model.RoleInstructor, ctx.StaffHandler))
+	// Staff control - remove a TA/teacher from the staff of a class: DELETE
+	router.HandleFunc("/v1/staff/{class}/{teacher_id}", ctx.RequireClassRole(model.RoleInstructor, model.RoleInstructor, ctx.SpecificStaffHandler))
+	// Invite control - GET the pending invites; invite a TA/teacher to register: GET, POST
+	router.HandleFunc("/v1/invite", ctx.RequireRole(model.RoleInstructor, ctx.InviteHandler))
+	// Invite control - revoke a pending invite: DELETE
+	router.HandleFunc("/v1/invite/{invite_id}", ctx.RequireRole(model.RoleAdmin, ctx.SpecificInviteHandler))
+	// Schedule control - GET the weekly labs and office hours of a class; replace them: GET, PUT
+	router.HandleFunc("/v1/schedule/{class}", ctx.RequireClassRole("", model.RoleInstructor, ctx.ScheduleHandler))
+	// Schedule control - GET the next labs and office hours of a class: GET
+	router.HandleFunc("/v1/schedule/{class}/next", ctx.NextMeetingsHandler)
+	// Lab session control - GET the lab sessions of a class; open one: GET, POST
+	router.HandleFunc("/v1/lab/{class}", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.LabSessionHandler))
+	// Lab session control - close the open lab session of a class and sum it up: POST
+	router.HandleFunc("/v1/lab/{class}/close", ctx.RequireClassRole(model.RoleTA, model.RoleTA, ctx.CloseLabSessionHandler))
+	// Admin control - rebuild the queues from mongo: POST
+	router.HandleFunc("/v1/admin/reconcile", ctx.RequireRole(model.RoleAdmin, ctx.ReconcileHandler))
 
 	log.Println("mongo:", mongoAddr)
 	log.Println("redis:",redisAddr)
 	log.Println("sessionKey:",sessionKey)
+	log.Println("retired session keys:",len(retiredKeys))
 	log.Println("mq:",rabbitAddr)
+	log.Println("joinURL:",joinURL)
 
 	log.Printf("microservice is running at http://%s", addr)
 	log.Fatal(http.ListenAndServe(addr, handler.NewLogger(router)))
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 	"log"
 	"questionqueue/src/model"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,6 +22,14 @@ const (
 	collTeacher  = "teacher"
 	collQuestion = "question"
 	collError	 = "error"
+	collEvent    = "event"
+	collNoShow   = "noshow"
+	collSchedule = "schedule"
+	collLab      = "labsession"
+	collSkip     = "skip"
+	collGroup    = "helpgroup"
+	collStaff    = "staff"
+	collInvite   = "invite"
 )
 
 var (
@@ -66,7 +76,7 @@ func (ms *MongoStore) InsertClass(class *model.Class) (*mongo.InsertOneResult, e
 // FindClass returns a `model.Class` using a `model.Class.Code`.
 func (ms *MongoStore) GetOneClass(code string) (*model.Class, error) {
 
-	cursor, err := ms.GetCollection(dbName, collClass).Find(nil, map[string]string{"Code": code}, nil)
+	cursor, err := ms.GetCollection(dbName, collClass).Find(nil, map[string]string{"class_number": code}, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -97,12 +107,12 @@ func (ms *MongoStore) GetAllClass() ([]*model.Class, error) {
 // UpdateClass takes a `model.Class` to overwrite a current class with a new `model.Class`.
 // Only needs `model.Class.Code` property
 func (ms *MongoStore) UpdateClass(old, new *model.Class) (*mongo.UpdateResult, error) {
-	return update(ms.GetCollection(dbName, collClass), map[string]string{"code": old.Code}, new)
+	return update(ms.GetCollection(dbName, collClass), map[string]string{"class_number": old.Code}, new)
 }
 
 // UpdateClass takes a class code to overwrite a current class with a new `model.Class`.
 func (ms *MongoStore) UpdateClassByCode(code string, new *model.Class) (*mongo.UpdateResult, error) {
-	return update(ms.GetCollection(dbName, collClass), map[string]string{"code": code}, new)
+	return update(ms.GetCollection(dbName, collClass), map[string]string{"class_number": code}, new)
 }
 
 // ScanClass takes a `mongo.Cursor`, parses all classes and return a slice of class pointers
@@ -121,6 +131,139 @@ func scanClass(cursor *mongo.Cursor) []*model.Class {
 	return class
 }
 
+/*
+Schedule
+*/
+
+// SetSchedule saves the weekly schedule of a class, replacing the one it had.
+func (ms *MongoStore) SetSchedule(schedule *model.Schedule) (*mongo.UpdateResult, error) {
+	return ms.GetCollection(dbName, collSchedule).
+		ReplaceOne(nil, bson.M{"class": schedule.Class}, schedule, options.Replace().SetUpsert(true))
+}
+
+// GetSchedule returns the weekly schedule of a class, or `mongo.ErrNoDocuments` if it has none.
+func (ms *MongoStore) GetSchedule(class string) (*model.Schedule, error) {
+	schedule := &model.Schedule{}
+	if err := ms.GetCollection(dbName, collSchedule).FindOne(nil, bson.M{"class": class}).Decode(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetAllSchedules returns the weekly schedules of all classes.
+func (ms *MongoStore) GetAllSchedules() ([]*model.Schedule, error) {
+	cursor, err := ms.getAll(dbName, collSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	var schedules []*model.Schedule
+	for cursor.Next(nil) {
+		s := model.Schedule{}
+		if err := cursor.Decode(&s); err != nil {
+			log.Printf("cannot unmarshal schedule: %v", err)
+			continue
+		} else {
+			schedules = append(schedules, &s)
+		}
+	}
+	return schedules, nil
+}
+
+/*
+Lab session
+*/
+
+// InsertLabSession adds a given `model.LabSession` to MongoDB.
+func (ms *MongoStore) InsertLabSession(lab *model.LabSession) (*mongo.InsertOneResult, error) {
+	return insert(ms.GetCollection(dbName, collLab), lab)
+}
+
+// GetOpenLabSession returns the lab session of a class that is not closed yet,
+// or `mongo.ErrNoDocuments` if there is none.
+func (ms *MongoStore) GetOpenLabSession(class string) (*model.LabSession, error) {
+	lab := &model.LabSession{}
+	if err := ms.GetCollection(dbName, collLab).
+		FindOne(nil, bson.M{"class": class, "closedat": bson.M{"$in": bson.A{time.Time{}, nil}}}).
+		Decode(lab); err != nil {
+		return nil, err
+	}
+	return lab, nil
+}
+
+// GetLabSessions returns the lab sessions of a class, most recently opened first.
+func (ms *MongoStore) GetLabSessions(class string) ([]*model.LabSession, error) {
+	cursor, err := ms.GetCollection(dbName, collLab).
+		Find(nil, bson.M{"class": class}, options.Find().SetSort(bson.M{"openedat": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	labs := []*model.LabSession{}
+	for cursor.Next(nil) {
+		l := model.LabSession{}
+		if err := cursor.Decode(&l); err != nil {
+			log.Printf("cannot unmarshal lab session: %v", err)
+			continue
+		} else {
+			labs = append(labs, &l)
+		}
+	}
+	return labs, nil
+}
+
+// CloseLabSession records when a lab session closed and its summary, found by its `model.LabSession.ID`.
+func (ms *MongoStore) CloseLabSession(lab *model.LabSession) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collLab),
+		bson.M{"_id": lab.ID},
+		bson.M{
+			"closedat": lab.ClosedAt,
+			"summary":  lab.Summary,
+		})
+}
+
+// GetLabSessionQuestions returns the questions asked in a lab session, in the order they were asked.
+func (ms *MongoStore) GetLabSessionQuestions(lab *model.LabSession) ([]*model.Question, error) {
+	if cursor, err := ms.GetCollection(dbName, collQuestion).
+		Find(nil,
+			bson.M{"labsessionid": lab.ID},
+			options.Find().SetSort(bson.M{"createdat": 1}));
+		err != nil {
+		return nil, err
+	} else {
+		return scanQuestion(cursor), nil
+	}
+}
+
+/*
+Help group
+*/
+
+// InsertHelpGroup adds a given `model.HelpGroup` to MongoDB.
+func (ms *MongoStore) InsertHelpGroup(group *model.HelpGroup) (*mongo.InsertOneResult, error) {
+	return insert(ms.GetCollection(dbName, collGroup), group)
+}
+
+// GetHelpGroup returns a group of a class by its ID, or `mongo.ErrNoDocuments` if there is none.
+func (ms *MongoStore) GetHelpGroup(class string, id primitive.ObjectID) (*model.HelpGroup, error) {
+	group := &model.HelpGroup{}
+	if err := ms.GetCollection(dbName, collGroup).FindOne(nil, bson.M{"_id": id, "class": class}).Decode(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// UpdateHelpGroup records the members and status of a group and when it was resolved, found by its `model.HelpGroup.ID`.
+func (ms *MongoStore) UpdateHelpGroup(group *model.HelpGroup) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collGroup),
+		bson.M{"_id": group.ID},
+		bson.M{
+			"members":    group.Members,
+			"status":     group.Status,
+			"resolvedat": group.ResolvedAt,
+		})
+}
+
 /*
 Question
 */
@@ -135,11 +278,59 @@ func (ms *MongoStore) GetAllQuestions() ([]*model.Question, error) {
 	}
 }
 
-// GetActiveQuestions returns all questions that have not been solved
-// by querying uninitialized `resolved_at` properties.
+// GetActiveQuestions returns all questions that have not been solved, the ones whose status says
+// they are still in the queue, in the order they were asked; see `ArchiveStatuslessQuestions`.
 func (ms *MongoStore) GetActiveQuestions() ([]*model.Question, error) {
 	if cursor, err := ms.GetCollection(dbName, collQuestion).
-		Find(nil, bson.M{"resolvedat": bson.M{"$eq": time.Time{}}}, nil);
+		Find(nil,
+			bson.M{
+				"status":     bson.M{"$in": model.ActiveStatuses()},
+				"resolvedat": bson.M{"$in": bson.A{time.Time{}, nil}},
+			},
+			options.Find().SetSort(bson.M{"createdat": 1}));
+		err != nil {
+		return nil, err
+	} else {
+		return scanQuestion(cursor), nil
+	}
+}
+
+// ArchiveStatuslessQuestions marks the questions asked before questions had a status as archived at a given time;
+// they left their queue long ago, so they must not be put back in line. It returns how many it marked.
+func (ms *MongoStore) ArchiveStatuslessQuestions(at time.Time) (int64, error) {
+	res, err := ms.GetCollection(dbName, collQuestion).
+		UpdateMany(nil,
+			bson.M{"status": bson.M{"$in": bson.A{"", nil}}},
+			bson.M{"$set": bson.M{"status": model.StatusArchived, "resolvedat": at}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// GetResolvedQuestions returns the questions of a class that were resolved since a given time.
+func (ms *MongoStore) GetResolvedQuestions(class string, since time.Time) ([]*model.Question, error) {
+	if cursor, err := ms.GetCollection(dbName, collQuestion).
+		Find(nil, bson.M{
+			"class":      class,
+			"status":     model.StatusResolved,
+			"resolvedat": bson.M{"$gte": since},
+		});
+		err != nil {
+		return nil, err
+	} else {
+		return scanQuestion(cursor), nil
+	}
+}
+
+// GetStudentQuestions returns the questions a student asked in a class since a given time.
+func (ms *MongoStore) GetStudentQuestions(class, id string, since time.Time) ([]*model.Question, error) {
+	if cursor, err := ms.GetCollection(dbName, collQuestion).
+		Find(nil, bson.M{
+			"class":     class,
+			"id":        id,
+			"createdat": bson.M{"$gte": since},
+		});
 		err != nil {
 		return nil, err
 	} else {
@@ -152,19 +343,93 @@ func (ms *MongoStore) InsertQuestion(question *model.Question) (*mongo.InsertOne
 	return insert(ms.GetCollection(dbName, collQuestion), question)
 }
 
-// Note: decommissioned on 3/12 as questions are maintained in redis
-// SolveQuestion takes a `question.belongsTo` and updates `question.resolvedAt` property to current time.
-//func (ms *MongoStore) SolveQuestion(belongsTo string) (*mongo.UpdateResult, error) {
-//	return ms.GetCollection(dbName, collQuestion).
-//		// use UpdateMany() instead of UpdateOne() since only one result should be matched
-//		UpdateMany(nil,
-//			bson.M{
-//				"belongsto":  bson.M{"$eq": belongsTo},
-//				"resolvedat": bson.M{"$eq": time.Time{}}},
-//			bson.M{
-//				"$set": bson.M{"resolvedat": time.Now()}},
-//			options.Update().SetBypassDocumentValidation(false))
-//}
+// UpdateQuestion overwrites the fields of a question document a student can change
+// with the given `model.Question`, found by its `model.Question.QuestionID`.
+func (ms *MongoStore) UpdateQuestion(question *model.Question) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collQuestion),
+		bson.M{"_id": question.QuestionID},
+		bson.M{
+			"name":        question.Name,
+			"topic":       question.Topic,
+			"description": question.Description,
+			"loc_x":       question.Loc_X,
+			"loc_y":       question.Loc_Y,
+		})
+}
+
+// SolveQuestion records how a question that left the queue was answered: when it left, by whom,
+// how long it took, in which group if any and its outcome, found by its `model.Question.QuestionID`.
+func (ms *MongoStore) SolveQuestion(question *model.Question) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collQuestion),
+		bson.M{"_id": question.QuestionID},
+		bson.M{
+			"status":       question.Status,
+			"claimedby":    question.ClaimedBy,
+			"claimedat":    question.ClaimedAt,
+			"resolvedat":   question.ResolvedAt,
+			"resolvedby":   question.ResolvedBy,
+			"helpduration": question.HelpDuration,
+			"groupid":      question.GroupID,
+		})
+}
+
+// IncrementNoShow adds one to the times a student was not there when a TA/teacher came to help them in a class.
+func (ms *MongoStore) IncrementNoShow(class, id string) (*mongo.UpdateResult, error) {
+	return ms.GetCollection(dbName, collNoShow).
+		UpdateOne(nil,
+			bson.M{"class": class, "id": id},
+			bson.M{"$inc": bson.M{"count": 1}},
+			options.Update().SetUpsert(true))
+}
+
+// GetNoShows returns how many times every student of a class was not there when a TA/teacher came to help them.
+func (ms *MongoStore) GetNoShows(class string) ([]*model.NoShow, error) {
+	cursor, err := ms.GetCollection(dbName, collNoShow).
+		Find(nil, bson.M{"class": class}, options.Find().SetSort(bson.M{"count": -1}))
+	if err != nil {
+		return nil, err
+	}
+
+	noShows := []*model.NoShow{}
+	for cursor.Next(nil) {
+		n := model.NoShow{}
+		if err := cursor.Decode(&n); err != nil {
+			log.Printf("cannot unmarshal no-show: %v", err)
+			continue
+		} else {
+			noShows = append(noShows, &n)
+		}
+	}
+	return noShows, nil
+}
+
+// InsertSkip records a student passed over by a TA/teacher helping someone behind them in line.
+func (ms *MongoStore) InsertSkip(skip *model.Skip) (*mongo.InsertOneResult, error) {
+	return insert(ms.GetCollection(dbName, collSkip), skip)
+}
+
+// GetSkips returns the students of a class passed over within [from, to], in the order it happened.
+func (ms *MongoStore) GetSkips(class string, from, to time.Time) ([]*model.Skip, error) {
+	cursor, err := ms.GetCollection(dbName, collSkip).
+		Find(nil,
+			bson.M{"class": class, "at": bson.M{"$gte": from, "$lte": to}},
+			options.Find().SetSort(bson.M{"at": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	skips := []*model.Skip{}
+	for cursor.Next(nil) {
+		s := model.Skip{}
+		if err := cursor.Decode(&s); err != nil {
+			log.Printf("cannot unmarshal skip: %v", err)
+			continue
+		} else {
+			skips = append(skips, &s)
+		}
+	}
+	return skips, nil
+}
 
 // ScanQuestion takes a `mongo.Cursor`, parses and return a slice of all classes found.
 func scanQuestion(cursor *mongo.Cursor) []*model.Question {
@@ -182,6 +447,69 @@ func scanQuestion(cursor *mongo.Cursor) []*model.Question {
 	return question
 }
 
+/*
+Event
+*/
+
+// InsertEvent appends a `model.QueueEvent`, numbered when the change it records was made, to the event log;
+// an event already in the log is left as it is, so that an event handed over twice is only logged once.
+func (ms *MongoStore) InsertEvent(event *model.QueueEvent) (*mongo.UpdateResult, error) {
+	return ms.GetCollection(dbName, collEvent).
+		ReplaceOne(nil, bson.M{"_id": event.ID}, event, options.Replace().SetUpsert(true))
+}
+
+// GetEvents returns the first `limit` events of the queue of a class that happened within [from, to]
+// in the order of their sequence numbers; a zero `from` starts at the first event.
+// An event that cannot be read fails the whole log, which would not be complete without it.
+func (ms *MongoStore) GetEvents(class string, from, to time.Time, limit int64) ([]*model.QueueEvent, error) {
+	cursor, err := ms.GetCollection(dbName, collEvent).
+		Find(nil,
+			bson.M{"class": class, "at": bson.M{"$gte": from, "$lte": to}},
+			options.Find().SetSort(bson.M{"seq": 1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*model.QueueEvent
+	for cursor.Next(nil) {
+		e := model.QueueEvent{}
+		if err := cursor.Decode(&e); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal event: %v", err)
+		}
+		events = append(events, &e)
+	}
+	return events, cursor.Err()
+}
+
+// GetPolicyEvent returns the last event of the queue of a class before a time that set its ordering policy,
+// or nil if there is none.
+func (ms *MongoStore) GetPolicyEvent(class string, before time.Time) (*model.QueueEvent, error) {
+	e := &model.QueueEvent{}
+	err := ms.GetCollection(dbName, collEvent).
+		FindOne(nil,
+			bson.M{"class": class, "policy": bson.M{"$ne": nil}, "at": bson.M{"$lt": before}},
+			options.FindOne().SetSort(bson.M{"seq": -1})).
+		Decode(e)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal event: %v", err)
+	}
+	return e, nil
+}
+
+// GetLastEventSeq returns the sequence number of the last event in the log, or 0 for an empty log.
+func (ms *MongoStore) GetLastEventSeq() (int64, error) {
+	e := model.QueueEvent{}
+	err := ms.GetCollection(dbName, collEvent).
+		FindOne(nil, bson.M{}, options.FindOne().SetSort(bson.M{"seq": -1})).
+		Decode(&e)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return e.Seq, err
+}
+
 /*
 Teacher
 */
@@ -203,6 +531,7 @@ func (ms *MongoStore) InsertTeacher(teacher *model.NewTeacher) (*mongo.InsertOne
 		PasswordHash string `json:"password_hash"`
 		FirstName    string `json:"first_name"`
 		LastName     string `json:"last_name"`
+		Role         string `json:"role"`
 	}
 
 	pwd, err := generatePassword(teacher.Password)
@@ -215,6 +544,7 @@ func (ms *MongoStore) InsertTeacher(teacher *model.NewTeacher) (*mongo.InsertOne
 		PasswordHash:  pwd,
 		FirstName:     teacher.FirstName,
 		LastName:      teacher.LastName,
+		Role:          teacher.Role,
 	})
 }
 
@@ -282,6 +612,34 @@ func (ms *MongoStore) GetTeacherByEmail(email string) ([]*model.Teacher, error)
 	}
 }
 
+// GetTeacherByID returns a teacher by their ID, or `mongo.ErrNoDocuments` if there is none.
+func (ms *MongoStore) GetTeacherByID(id primitive.ObjectID) (*model.Teacher, error) {
+	teacher := &model.Teacher{}
+	if err := ms.GetCollection(dbName, collTeacher).FindOne(nil, bson.M{"_id": id}).Decode(teacher); err != nil {
+		return nil, err
+	}
+	return teacher, nil
+}
+
+// SetTeacherRole changes the role of the account of a teacher.
+func (ms *MongoStore) SetTeacherRole(id primitive.ObjectID, role string) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collTeacher), bson.M{"_id": id}, bson.M{"role": role})
+}
+
+// SetTeacherPassword changes the password of a teacher.
+func (ms *MongoStore) SetTeacherPassword(id primitive.ObjectID, password string) (*mongo.UpdateResult, error) {
+	pwd, err := generatePassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return update(ms.GetCollection(dbName, collTeacher), bson.M{"_id": id}, bson.M{"passwordhash": pwd})
+}
+
+// SetEmailVerified marks the email of a teacher as verified.
+func (ms *MongoStore) SetEmailVerified(id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collTeacher), bson.M{"_id": id}, bson.M{"emailverified": true})
+}
+
 // GetAllTeacher returns all teacher documents from MongoDB.
 func (ms *MongoStore) GetAllTeacher() ([]*model.Teacher, error) {
 	cursor, err := ms.getAll(dbName, collTeacher)
@@ -307,6 +665,118 @@ func scanTeacher(cursor *mongo.Cursor) []*model.Teacher {
 	return teacher
 }
 
+/*
+Staff
+*/
+
+// SetStaff adds a teacher to the staff of a class, or changes their role if they already are.
+func (ms *MongoStore) SetStaff(staff *model.Staff) (*mongo.UpdateResult, error) {
+	return ms.GetCollection(dbName, collStaff).
+		ReplaceOne(nil, bson.M{"class": staff.Class, "teacherid": staff.TeacherID}, staff, options.Replace().SetUpsert(true))
+}
+
+// GetStaff returns the membership of a teacher of the staff of a class, or `mongo.ErrNoDocuments` if they are not.
+func (ms *MongoStore) GetStaff(class, teacherID string) (*model.Staff, error) {
+	staff := &model.Staff{}
+	if err := ms.GetCollection(dbName, collStaff).
+		FindOne(nil, bson.M{"class": class, "teacherid": teacherID}).Decode(staff); err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// GetClassStaff returns the staff of a class, instructors first.
+func (ms *MongoStore) GetClassStaff(class string) ([]*model.Staff, error) {
+	cursor, err := ms.GetCollection(dbName, collStaff).
+		Find(nil, bson.M{"class": class}, options.Find().SetSort(bson.D{{Key: "role", Value: 1}, {Key: "addedat", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	staff := []*model.Staff{}
+	for cursor.Next(nil) {
+		s := model.Staff{}
+		if err := cursor.Decode(&s); err != nil {
+			log.Printf("cannot unmarshal staff: %v", err)
+			continue
+		} else {
+			staff = append(staff, &s)
+		}
+	}
+	return staff, nil
+}
+
+// RemoveStaff removes a teacher from the staff of a class.
+func (ms *MongoStore) RemoveStaff(class, teacherID string) (*mongo.DeleteResult, error) {
+	return ms.GetCollection(dbName, collStaff).DeleteOne(nil, bson.M{"class": class, "teacherid": teacherID})
+}
+
+/*
+Invite
+*/
+
+// InsertInvite adds a given `model.Invite` to MongoDB.
+func (ms *MongoStore) InsertInvite(invite *model.Invite) (*mongo.InsertOneResult, error) {
+	return insert(ms.GetCollection(dbName, collInvite), invite)
+}
+
+// GetPendingInvites returns the invites that can still be used at a given time, the ones expiring first first.
+func (ms *MongoStore) GetPendingInvites(at time.Time) ([]*model.Invite, error) {
+	cursor, err := ms.GetCollection(dbName, collInvite).
+		Find(nil, pendingInvite(at), options.Find().SetSort(bson.M{"expiresat": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	invites := []*model.Invite{}
+	for cursor.Next(nil) {
+		i := model.Invite{}
+		if err := cursor.Decode(&i); err != nil {
+			log.Printf("cannot unmarshal invite: %v", err)
+			continue
+		} else {
+			invites = append(invites, &i)
+		}
+	}
+	return invites, nil
+}
+
+// UseInvite atomically marks the invite of a token hash for an email as used at a given time and returns it,
+// or `mongo.ErrNoDocuments` if there is no such invite still pending.
+func (ms *MongoStore) UseInvite(tokenHash, email string, at time.Time) (*model.Invite, error) {
+	filter := pendingInvite(at)
+	filter["tokenhash"] = tokenHash
+	filter["email"] = strings.ToLower(email)
+
+	invite := &model.Invite{}
+	err := ms.GetCollection(dbName, collInvite).
+		FindOneAndUpdate(nil, filter,
+			bson.M{"$set": bson.M{"usedat": at}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After)).
+		Decode(invite)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// ReleaseInvite makes a used invite pending again, when registering with it failed.
+func (ms *MongoStore) ReleaseInvite(id primitive.ObjectID) (*mongo.UpdateResult, error) {
+	return update(ms.GetCollection(dbName, collInvite), bson.M{"_id": id}, bson.M{"usedat": time.Time{}})
+}
+
+// RevokeInvite revokes an invite still pending at a given time.
+func (ms *MongoStore) RevokeInvite(id primitive.ObjectID, at time.Time) (*mongo.UpdateResult, error) {
+	filter := pendingInvite(at)
+	filter["_id"] = id
+	return update(ms.GetCollection(dbName, collInvite), filter, bson.M{"revokedat": at})
+}
+
+// pendingInvite returns the filter of the invites that can still be used at a given time.
+func pendingInvite(at time.Time) bson.M {
+	return bson.M{"usedat": time.Time{}, "revokedat": time.Time{}, "expiresat": bson.M{"$gt": at}}
+}
+
 /*
 Error
 */
@@ -3,6 +3,8 @@ package db
 import (
 	"encoding/json"
 	"log"
+	"questionqueue/src/model"
+	"time"
 )
 
 // connector
@@ -19,12 +21,23 @@ func ExampleMongoStore_GetActiveQuestions() {
 	}
 }
 
-//func ExampleMongoStore_SolveQuestion() {
-//	const name = "someone"
-//	res, err := ms.SolveQuestion(name)
-//	if err != nil {
-//		log.Printf("cannot update question belongs to %v: %v", name, err)
-//	} else {
-//		log.Println(res.ModifiedCount)
-//	}
-//}
\ No newline at end of file
+func ExampleMongoStore_SolveQuestion() {
+	questions, err := ms.GetActiveQuestions()
+	if err != nil || len(questions) == 0 {
+		log.Printf("no question to solve: %v", err)
+		return
+	}
+
+	q := questions[0]
+	if err := q.Transition(model.StatusWithdrawn, "", time.Now()); err != nil {
+		log.Printf("cannot withdraw question of %v: %v", q.ID, err)
+		return
+	}
+
+	res, err := ms.SolveQuestion(q)
+	if err != nil {
+		log.Printf("cannot update question of %v: %v", q.ID, err)
+	} else {
+		log.Println(res.ModifiedCount)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"net/url"
+	"questionqueue/src/mail"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"strings"
+	"time"
+)
+
+var ErrEmailVerified = errors.New("the email is already verified")
+
+// PasswordResetHandler sends a TA/teacher who forgot their password a link to reset it, and resets it with the token of the link.
+func (ctx *Context) PasswordResetHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+		http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	switch r.Method {
+	// send a reset link; the response is the same whether the email has an account or not
+	case http.MethodPost:
+
+		prr, err := decodePasswordResetRequest(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		teachers, err := ctx.MongoStore.GetTeacherByEmail(prr.Email)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, t := range teachers {
+			if err := ctx.sendAccountToken(t, model.PurposeResetPassword); err != nil {
+				log.Printf("cannot send a reset link to %v: %v", t.ID.Hex(), err)
+			}
+		}
+
+		httpWriter(http.StatusAccepted, []byte("a reset link was sent if the email has an account"), MimePlain, w)
+
+	// set a new password with a reset token; every session of the TA/teacher is signed out
+	case http.MethodPut:
+
+		pr, err := decodePasswordReset(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if err := pr.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		teacher, err := ctx.useAccountToken(pr.Token, model.PurposeResetPassword)
+		if err == model.ErrInvalidAccountToken {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := ctx.MongoStore.SetTeacherPassword(teacher.ID, pr.Password); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := ctx.SessionStore.RevokeSessions(teacher.ID.Hex(), session.InvalidSessionID); err != nil {
+			log.Printf("cannot sign out %v after resetting their password: %v", teacher.ID.Hex(), err)
+		}
+
+		httpWriter(http.StatusOK, []byte("password reset"), MimePlain, w)
+	}
+}
+
+// EmailVerificationHandler sends a TA/teacher a link to verify their email, and verifies it with the token of the link.
+func (ctx *Context) EmailVerificationHandler(w http.ResponseWriter, r *http.Request) {
+
+	switch r.Method {
+	// send the TA/teacher of the session a new verify-email link
+	case http.MethodPost:
+
+		teacher, err := ctx.getTeacher(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		// the session may be older than the verification
+		current, err := ctx.getTeacherByID(teacher.ID.Hex())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if current.EmailVerified {
+			http.Error(w, ErrEmailVerified.Error(), http.StatusConflict)
+			return
+		}
+
+		if err := ctx.sendAccountToken(current, model.PurposeVerifyEmail); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		httpWriter(http.StatusAccepted, []byte("a verify-email link was sent"), MimePlain, w)
+
+	// verify the email a verify-email token was sent to
+	case http.MethodPut:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		ev, err := decodeEmailVerification(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		teacher, err := ctx.useAccountToken(ev.Token, model.PurposeVerifyEmail)
+		if err == model.ErrInvalidAccountToken {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if _, err := ctx.MongoStore.SetEmailVerified(teacher.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		teacher.EmailVerified = true
+		teacher.PasswordHash = ""
+
+		// an admin by `Context.AdminEmails` is one from now on
+		ctx.ensureAdmin(teacher)
+
+		b, _ := json.Marshal(teacher)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// sendAccountToken mails a TA/teacher the link to do `purpose` with a new token.
+func (ctx *Context) sendAccountToken(t *model.Teacher, purpose string) error {
+	token, err := model.NewAccountToken(purpose, t, ctx.Keys.Current, time.Now())
+	if err != nil {
+		return err
+	}
+
+	m := &mail.Message{To: t.Email}
+	switch purpose {
+	case model.PurposeResetPassword:
+		m.Subject = "Reset your QuestionQueue password"
+		m.Body = "Someone asked to reset the password of your QuestionQueue account. " +
+			"If it was you, set a new password within the hour at:\n\n" +
+			accountLink(ctx.ResetURL, token) + "\n\nOtherwise, you can ignore this email."
+	case model.PurposeVerifyEmail:
+		m.Subject = "Verify your QuestionQueue email"
+		m.Body = "Verify the email of your QuestionQueue account within 48 hours at:\n\n" +
+			accountLink(ctx.VerifyURL, token) + "\n"
+	}
+	return ctx.Mailer.Send(m)
+}
+
+// useAccountToken returns the TA/teacher a token for `purpose` was made for,
+// or `model.ErrInvalidAccountToken` if it is invalid, expired or no longer of use.
+func (ctx *Context) useAccountToken(token, purpose string) (*model.Teacher, error) {
+	tok, err := model.ParseAccountToken(token, purpose, ctx.Keys.Accepted(time.Now()), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(tok.TeacherID)
+	if err != nil {
+		return nil, model.ErrInvalidAccountToken
+	}
+
+	teacher, err := ctx.MongoStore.GetTeacherByID(id)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrInvalidAccountToken
+	} else if err != nil {
+		return nil, err
+	}
+	if !tok.Matches(teacher) {
+		return nil, model.ErrInvalidAccountToken
+	}
+	return teacher, nil
+}
+
+// accountLink returns the link to a page with a token.
+func accountLink(page, token string) string {
+	return page + "?" + url.Values{"token": {token}}.Encode()
+}
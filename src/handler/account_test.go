@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"questionqueue/src/mail"
+	"questionqueue/src/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendAccountToken(t *testing.T) {
+	ctx := newTestContext()
+	mailer := mail.NewMemorySender()
+	ctx.Mailer = mailer
+	ctx.VerifyURL = "http://localhost:3000/verify"
+
+	teacher := &model.Teacher{ID: primitive.NewObjectID(), Email: "ta@uw.edu"}
+	if err := ctx.sendAccountToken(teacher, model.PurposeVerifyEmail); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sent := mailer.Sent()
+	if len(sent) != 1 || sent[0].To != "ta@uw.edu" {
+		t.Fatalf("expected one email to the TA/teacher, got %v", sent)
+	}
+
+	i := strings.Index(sent[0].Body, ctx.VerifyURL)
+	if i < 0 {
+		t.Fatalf("expected the link in the email, got %q", sent[0].Body)
+	}
+	link, err := url.Parse(strings.Fields(sent[0].Body[i:])[0])
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tok, err := model.ParseAccountToken(link.Query().Get("token"), model.PurposeVerifyEmail, ctx.Keys.Accepted(time.Now()), time.Now())
+	if err != nil || !tok.Matches(teacher) {
+		t.Errorf("expected the token of the link to verify the email of the TA/teacher, got %v", err)
+	}
+}
+
+func TestPasswordResetHandler_InvalidToken(t *testing.T) {
+	ctx := newTestContext()
+
+	body := `{"token":"not-a-token","password":"password","password_conf":"password"}`
+	r := httptest.NewRequest(http.MethodPut, "/v1/teacher/password", strings.NewReader(body))
+	r.Header.Set("Content-Type", MimeJson)
+	w := httptest.NewRecorder()
+	ctx.PasswordResetHandler(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected %v, got %v", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestEmailVerificationHandler_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/teacher/verify", nil)
+	w := httptest.NewRecorder()
+	ctx.EmailVerificationHandler(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %v, got %v", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"log"
 	"net/http"
 	"questionqueue/src/db"
@@ -19,14 +20,38 @@ var (
 	ErrUnsupportedMediaType = errors.New("unsupported media type")
 	ErrInvalidCredentials   = errors.New("invalid credentials")
 	ErrMethodNotAllowed     = errors.New("method not allowed")
-	ErrQuestionNotFound		= errors.New("question not found")
+	ErrQuestionNotFound		= session.ErrQuestionNotFound
+	ErrInvalidClass         = errors.New("invalid class code")
+	ErrUnknownAction        = errors.New("unknown action")
 )
 
 const (
 	MimeJson  = "application/json"
 	MimePlain = "text/plain"
+	MimePng   = "image/png"
 )
 
+// questionActions maps the endpoints of `QuestionStatusHandler` to the status they change a question to.
+var questionActions = map[string]string{
+	"claim":    model.StatusClaimed,
+	"release":  model.StatusWaiting,
+	"start":    model.StatusInProgress,
+	"resolve":  model.StatusResolved,
+	"noshow":   model.StatusNoShow,
+	"withdraw": model.StatusWithdrawn,
+}
+
+// statusMessageTypes maps the status a question changes to to the type of the message sent about it.
+var statusMessageTypes = map[string]string{
+	model.StatusWaiting:    notifier.QuestionRequeue,
+	model.StatusClaimed:    notifier.QuestionClaimed,
+	model.StatusInProgress: notifier.QuestionInProgress,
+	model.StatusResolved:   notifier.QuestionResolved,
+	model.StatusNoShow:     notifier.QuestionNoShow,
+	model.StatusWithdrawn:  notifier.QuestionWithdrawn,
+	model.StatusArchived:   notifier.QuestionArchived,
+}
+
 func (ctx *Context) OkHandler(w http.ResponseWriter, r *http.Request) {
 	httpWriter(http.StatusOK, []byte("connected"), MimePlain, w)
 	return
@@ -56,7 +81,28 @@ func (ctx *Context) TeacherHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// only invited TA/teachers can register, but admins by `Context.AdminEmails`, who invite the first ones
+		// once they verified their email, see `ensureAdmin`
+		invite, err := ctx.useInvite(nt)
+		if err == model.ErrInvalidInvite {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// new TA/teachers have the role they were invited with, and are on the staff of no class
+		// but the one they were invited to, if any
+		nt.Role = model.RoleTA
+		if invite != nil {
+			nt.Role = invite.Role
+		}
+
 		res, err := ctx.MongoStore.InsertTeacher(nt)
+		if err != nil {
+			ctx.releaseInvite(invite)
+		}
 		if err == db.ErrEmailUsed {
 			http.Error(w, err.Error(), http.StatusConflict)
 			return
@@ -70,15 +116,18 @@ func (ctx *Context) TeacherHandler(w http.ResponseWriter, r *http.Request) {
 			Email:     nt.Email,
 			FirstName: nt.FirstName,
 			LastName:  nt.LastName,
+			Role:      nt.Role,
 		}
 
-		newSessionState := session.State{
-			SessionStart: time.Now(),
-			Interface:    t,
+		if invite != nil && len(invite.Class) != 0 {
+			ctx.joinInvitedStaff(invite, t.ID)
 		}
 
-		_, err = session.BeginSession(ctx.Key, ctx.SessionStore, newSessionState, w)
-		if err != nil {
+		if err := ctx.sendAccountToken(&t, model.PurposeVerifyEmail); err != nil {
+			log.Printf("cannot send a verify-email link to %v: %v", t.ID.Hex(), err)
+		}
+
+		if err := ctx.beginSession(w, r, t); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -97,13 +146,13 @@ func (ctx *Context) TeacherHandler(w http.ResponseWriter, r *http.Request) {
 
 		// get current state and session ID
 		currentState := &session.State{}
-		_, err := session.GetState(r, ctx.Key, ctx.SessionStore, currentState)
+		_, err := session.GetState(r, ctx.Keys, ctx.SessionStore, currentState)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
-		sid, err := session.GetSessionID(r, ctx.Key)
+		sid, err := session.GetSessionID(r, ctx.Keys)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
@@ -141,9 +190,12 @@ func (ctx *Context) TeacherHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "got more than one profile", http.StatusInternalServerError)
 		}
 
+		// the session keeps when and from which device it started
 		newState := session.State{
-			SessionStart: time.Now(),
+			SessionStart: currentState.SessionStart,
 			Interface:    currentTeacher[0],
+			UserAgent:    currentState.UserAgent,
+			IP:           currentState.IP,
 		}
 
 		if err := ctx.SessionStore.Save(sid, newState); err != nil {
@@ -151,6 +203,13 @@ func (ctx *Context) TeacherHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// a new password signs out every other session of the TA/teacher
+		if tu.NewPassword != tu.OldPassword {
+			if _, err := ctx.SessionStore.RevokeSessions(currentTeacher[0].ID.Hex(), sid); err != nil {
+				log.Printf("cannot sign out the other sessions of %v: %v", currentTeacher[0].ID.Hex(), err)
+			}
+		}
+
 		// `res.UpsertedID` should match whatever the original session ID is, *double check* redis
 		js, err := json.Marshal(currentTeacher[0])
 		if err != nil {
@@ -178,7 +237,7 @@ func (ctx *Context) TeacherProfileHandler(w http.ResponseWriter, r *http.Request
 	// get current user profile
 	case "me":
 		currentState := &session.State{}
-		_, err := session.GetState(r, ctx.Key, ctx.SessionStore, currentState)
+		_, err := session.GetState(r, ctx.Keys, ctx.SessionStore, currentState)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
@@ -188,20 +247,26 @@ func (ctx *Context) TeacherProfileHandler(w http.ResponseWriter, r *http.Request
 
 		httpWriter(http.StatusOK, b, MimeJson, w)
 
-	// get all teachers, they are authorized to do so
+	// get all teachers, if they are an admin
 	case "all":
-		// current state discarded
-		_, err := session.GetState(r, ctx.Key, ctx.SessionStore, &session.State{})
+		teacher, err := ctx.getTeacher(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		if teacher.AccountRole() != model.RoleAdmin {
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
 
 		teachers, err := ctx.MongoStore.GetAllTeacher()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		for _, t := range teachers {
+			t.PasswordHash = ""
+		}
 
 		b, _ := json.Marshal(teachers)
 		httpWriter(http.StatusOK, b, MimeJson, w)
@@ -232,11 +297,10 @@ func (ctx *Context) TeacherSessionHandler(w http.ResponseWriter, r *http.Request
 			return
 		}
 
-		newSessionState := session.State{
-			SessionStart: time.Now(),
-			Interface:    t,
+		if err := ctx.beginSession(w, r, *t); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		_, err = session.BeginSession(ctx.Key, ctx.SessionStore, newSessionState, w)
 
 		js, _ := json.Marshal(t)
 		httpWriter(http.StatusOK, js, MimeJson, w)
@@ -244,21 +308,22 @@ func (ctx *Context) TeacherSessionHandler(w http.ResponseWriter, r *http.Request
 	// delete session
 	case http.MethodDelete:
 
-		var err error
-
-		// `State` is discarded
-		_, err = session.GetState(r, ctx.Key, ctx.SessionStore, &session.State{})
+		teacher, err := ctx.getTeacher(r)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 
-		_, err = session.EndSession(r, ctx.Key, ctx.SessionStore)
+		sid, err := session.EndSession(r, ctx.Keys, ctx.SessionStore)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
+		if err := ctx.SessionStore.UnindexSession(teacher.ID.Hex(), sid); err != nil {
+			log.Printf("cannot remove session of %v from their sessions: %v", teacher.ID.Hex(), err)
+		}
+
 		httpWriter(http.StatusOK, []byte("you have been signed out"), MimeJson, w)
 
 	default:
@@ -268,10 +333,8 @@ func (ctx *Context) TeacherSessionHandler(w http.ResponseWriter, r *http.Request
 
 }
 
-// PostQuestionHandler posts new question to mongo and enqueues the question to redis in the format of
-// {queue : [
-// 		{model.Question1}, {model.Question2}, ..., {model.QuestionN}
-// ]}
+// PostQuestionHandler posts new question to mongo and enqueues the question to the redis queue
+// of its class; see `session.RedisStore.Enqueue`.
 func (ctx *Context) PostQuestionHandler(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
@@ -283,25 +346,106 @@ func (ctx *Context) PostQuestionHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		nq, err := decodeQuestion(r.Body)
+		// only what a student can say about their question is taken from the request
+		asked, err := decodeNewQuestion(r.Body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
 			return
 		}
+		nq := asked.ToQuestion()
+
+		if !model.ValidateClass(nq.Class) {
+			http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+			return
+		}
 
+		// students line up with the current join code of the class, see `JoinCodeHandler`
+		if err := verifyJoinCode(ctx, nq.Class, r.URL.Query().Get("code")); err == model.ErrInvalidJoinCode {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		nq.QuestionID = primitive.NewObjectID()
 		nq.CreatedAt = time.Now()
+		nq.Status = model.StatusWaiting
+
+		// the question belongs to the lab session of the class open now, if any
+		since := nq.CreatedAt.Add(-model.SessionWindow)
+		if lab, err := ctx.MongoStore.GetOpenLabSession(nq.Class); err == nil {
+			nq.LabSessionID = lab.ID
+			// questions resolved within the last hour count even from before the lab session
+			if since = lab.OpenedAt; since.After(nq.CreatedAt.Add(-time.Hour)) {
+				since = nq.CreatedAt.Add(-time.Hour)
+			}
+		} else if err != mongo.ErrNoDocuments {
+			log.Printf("cannot find the lab session of %v: %v", nq.Class, err)
+		}
+
+		// the ordering policies of the queue go by the earlier questions of the student
+		if earlier, err := ctx.MongoStore.GetStudentQuestions(nq.Class, nq.ID, since); err == nil {
+			nq.SetHistory(earlier, nq.CreatedAt)
+		} else {
+			log.Printf("cannot find the earlier questions of %v: %v", nq.ID, err)
+		}
+
+		state, err := ctx.SessionStore.GetQueueState(nq.Class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-		if err := enqueueQuestion(ctx, nq); err != nil {
+		token, err := session.NewOwnerToken()
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		// the owner of a question put back in line from mongo can still prove it is theirs
+		nq.OwnerHash = session.HashOwnerToken(token)
+
+		existing, err := enqueueQuestion(ctx, nq, state, token)
+		if isQueueRejection(err) {
+			// tell the student why along with the state of the queue, e.g. the message of a paused queue
+			b, _ := json.Marshal(&QueueRejection{Error: err.Error(), State: state})
+			httpWriter(http.StatusForbidden, b, MimeJson, w)
+			return
+
+		} else if err == session.ErrQuestionExists {
+			// a student only has one question per queue; the question is only updated
+			// in place, keeping the student's place in line, when asked to
+			if r.URL.Query().Get("update") != "true" {
+				b, _ := json.Marshal(existing)
+				httpWriter(http.StatusConflict, b, MimeJson, w)
+				return
+			}
+
+			// only the student who asked can update their question
+			if err := verifyOwner(ctx, r, nq.Class, nq.ID); err != nil {
+				http.Error(w, err.Error(), ownerErrorStatus(err))
+				return
+			}
+
+			updated, err := updateQuestion(ctx, nq)
+			if err == ErrQuestionNotFound {
+				http.Error(w, "the question left the queue while being updated, please try again", http.StatusConflict)
+				return
+			} else if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			b, _ := json.Marshal(updated)
+			httpWriter(http.StatusOK, b, MimeJson, w)
+			return
 
-		if _, err := ctx.MongoStore.InsertQuestion(nq); err != nil {
+		} else if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		b, _ := json.Marshal(nq)
+		b, _ := json.Marshal(&PostedQuestion{Question: nq, Token: token})
 		httpWriter(http.StatusCreated, b, MimeJson, w)
 		return
 
@@ -311,25 +455,95 @@ func (ctx *Context) PostQuestionHandler(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// DeleteQuestionHandler removes a question from the redis question queue
-func (ctx *Context) DeleteQuestionHandler(w http.ResponseWriter, r *http.Request) {
+// SpecificQuestionHandler reads the position of a question in the redis question queue of the class
+// given in the `class` query parameter, and lets the student who asked it, proven by the token in
+// `OwnerTokenHeader`, update it in place or withdraw it; a TA/teacher can withdraw it too.
+func (ctx *Context) SpecificQuestionHandler(w http.ResponseWriter, r *http.Request) {
+
+	id := mux.Vars(r)["id"]
+	if len(id) == 0 {
+		http.Error(w, "you have to provide an question ID", http.StatusBadRequest)
+		return
+	}
+
+	class := r.URL.Query().Get("class")
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
 
 	switch r.Method {
+	// get the position in line of the student
+	case http.MethodGet:
+
+		position, err := ctx.SessionStore.GetPosition(class, id)
+		if err == ErrQuestionNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(position)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// update the question, keeping the student's place in line
+	case http.MethodPatch:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if err := verifyOwner(ctx, r, class, id); err != nil {
+			http.Error(w, err.Error(), ownerErrorStatus(err))
+			return
+		}
+
+		nq, err := decodeQuestion(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+		nq.Class = class
+		nq.ID = id
+
+		updated, err := updateQuestion(ctx, nq)
+		if err == ErrQuestionNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(updated)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// withdraw the question, on behalf of a TA/teacher or the student who asked
 	case http.MethodDelete:
 
-		id := mux.Vars(r)["id"]
-		if len(id) == 0 {
-			http.Error(w, "you have to provide an question ID to dequeue", http.StatusBadRequest)
+		teacherID := ""
+		if hasSession(r) {
+			// only the staff of the class can withdraw a student
+			teacher, ok := ctx.authorizeClass(w, r, class, model.RoleTA)
+			if !ok {
+				return
+			}
+			teacherID = teacher.ID.Hex()
+		} else if err := verifyOwner(ctx, r, class, id); err != nil {
+			http.Error(w, err.Error(), ownerErrorStatus(err))
 			return
 		}
 
-		q, err := dequeueQuestion(ctx, id)
+		q, err := transitionQuestion(ctx, class, id, model.StatusWithdrawn, teacherID)
 		if err != nil {
 			if err == ErrQuestionNotFound {
 				http.Error(w, ErrQuestionNotFound.Error(), http.StatusNotFound)
 				return
 			} else {
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 		}
@@ -344,75 +558,224 @@ func (ctx *Context) DeleteQuestionHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
-// UpdateQueue commits an update to Redis and MessageQueue
-func enqueueQuestion(ctx *Context, nq *model.Question) error {
+// QueueHandler returns the entire question queue of a class to a TA/teacher.
+func (ctx *Context) QueueHandler(w http.ResponseWriter, r *http.Request) {
 
-	// get current queue from redis
-	currentQueue := model.QuestionQueue{}
-	err := ctx.SessionStore.GetQueue(&currentQueue)
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// current state discarded
+	_, err := session.GetState(r, ctx.Keys, ctx.SessionStore, &session.State{})
 	if err != nil {
-		// `redis: nil` == empty redis, ignore
-		if err.Error() != "redis: nil" {
-			return err
-		}
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
 
-	currentQueue.Queue = append(currentQueue.Queue, nq)
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
 
-	// update redis
-	if err := ctx.SessionStore.SetQueue(currentQueue); err != nil {
-		return err
+	currentQueue, err := ctx.SessionStore.GetQueue(class)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	// create message and push to mq
-	ctx.Notifier.PublishMessage(&notifier.Message{
-		Type:    notifier.QuestionNew,
-		Content: nq,
-		UserID:  nq.ID,
-	})
+	b, _ := json.Marshal(currentQueue)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// QuestionStatusHandler lets a TA/teacher move a question in the queue of a class through its statuses;
+// every action has its own endpoint, see `questionActions`.
+func (ctx *Context) QuestionStatusHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if !model.ValidateClass(vars["class"]) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, ok := questionActions[vars["action"]]
+	if !ok {
+		http.Error(w, ErrUnknownAction.Error(), http.StatusNotFound)
+		return
+	}
+
+	q, err := transitionQuestion(ctx, vars["class"], vars["id"], status, teacher.ID.Hex())
+	switch err {
+	case nil:
+	case ErrQuestionNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case model.ErrInvalidTransition, model.ErrClaimedByOther, model.ErrOverdueAhead:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(q)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// enqueueQuestion atomically adds a question to the queue of its class in redis if its `state` admits it,
+// owned by whoever has `token`, saves it to mongo and notifies the event log and the MessageQueue; if the
+// student is already in line, the existing question is returned with `session.ErrQuestionExists`.
+// A question mongo cannot save is withdrawn again, so nobody waits in line without a record of it
+func enqueueQuestion(ctx *Context, nq *model.Question, state *model.QueueState, token string) (*model.Question, error) {
+
+	if existing, err := ctx.SessionStore.EnqueueIfOpen(nq, state, token, newEvent(notifier.QuestionNew, "")); err != nil {
+		return existing, err
+	}
+
+	if _, err := ctx.MongoStore.InsertQuestion(nq); err != nil {
+		// the event log saw the question join the line, so it sees it leave too
+		if _, err := ctx.SessionStore.UpdateQuestion(nq.Class, nq.ID, func(q *model.Question) error {
+			return q.Transition(model.StatusWithdrawn, "", time.Now())
+		}, newEvent(notifier.QuestionWithdrawn, "")); err != nil {
+			log.Printf("cannot take question %v out of line after failing to save it: %v", nq.QuestionID.Hex(), err)
+		}
+		return nil, err
+	}
 
-	return nil
+	ctx.notify(notifier.QuestionNew, nq)
+
+	return nil, nil
 }
 
-func dequeueQuestion(ctx *Context, id string) (*model.Question, error) {
-	// get current queue from redis
-	currentQueue := model.QuestionQueue{}
-	err := ctx.SessionStore.GetQueue(&currentQueue)
+// updateQuestion overwrites the question a student already has in the queue of its class with
+// a new one, keeping their place in line, then saves it to mongo and notifies the MessageQueue
+func updateQuestion(ctx *Context, nq *model.Question) (*model.Question, error) {
+
+	updated, err := ctx.SessionStore.UpdateQuestion(nq.Class, nq.ID, func(q *model.Question) error {
+		q.Merge(nq)
+		return nil
+	}, newEvent(notifier.QuestionUpdate, ""))
 	if err != nil {
-		// `redis: nil` == empty redis, ignore
-		if err.Error() != "redis: nil" {
-			return nil, err
+		return nil, err
+	}
+
+	if _, err := ctx.MongoStore.UpdateQuestion(updated); err != nil {
+		return nil, err
+	}
+
+	ctx.notify(notifier.QuestionUpdate, updated)
+
+	return updated, nil
+}
+
+// transitionQuestion atomically changes the status of a question in the queue of a class on behalf
+// of the TA/teacher with `teacherID` and notifies the MessageQueue; questions that are done leave the queue.
+// Claims go through `claimQuestion`, so that they count the students they pass over
+func transitionQuestion(ctx *Context, class, id, status, teacherID string) (*model.Question, error) {
+	if status == model.StatusClaimed {
+		return claimQuestion(ctx, class, id, teacherID)
+	}
+	return changeStatus(ctx, class, id, status, teacherID, statusMessageTypes[status], nil)
+}
+
+// changeStatus changes the status of a question like `transitionQuestion`, applying `before` to it first
+// in the same update, if given, and notifying with a message of type `messageType`
+func changeStatus(ctx *Context, class, id, status, teacherID, messageType string, before func(*model.Question) error) (*model.Question, error) {
+
+	q, err := ctx.SessionStore.UpdateQuestion(class, id, func(q *model.Question) error {
+		if before != nil {
+			if err := before(q); err != nil {
+				return err
+			}
 		}
+		if err := q.Transition(status, teacherID, time.Now()); err != nil {
+			return err
+		}
+		if status == model.StatusNoShow {
+			q.NoShows++
+		}
+		return nil
+	}, newEvent(messageType, teacherID))
+	if err != nil {
+		return nil, err
+	}
+
+	if status == model.StatusNoShow {
+		ctx.countNoShow(class, id)
 	}
 
-	// remove question from currentQueue
-	// linear search
-	var removedQuestion *model.Question
-	for i, q := range currentQueue.Queue {
-		if q.ID == id {
-			removedQuestion = q
-			currentQueue.Queue = append(currentQueue.Queue[:i], currentQueue.Queue[i+1:]...)
-			break
+	// record how the question was answered once it left the queue
+	if !q.IsActive() {
+		if _, err := ctx.MongoStore.SolveQuestion(q); err != nil {
+			log.Printf("cannot record the resolution of question %v: %v", q.QuestionID.Hex(), err)
 		}
 	}
 
-	// update redis
-	if err := ctx.SessionStore.SetQueue(currentQueue); err != nil {
-		return nil, err
+	// who is helping and how long it takes changed; the estimated wait goes out with the notification
+	if err := ctx.refreshWaitStats(class); err != nil {
+		log.Printf("cannot refresh the wait statistics of %v: %v", class, err)
 	}
 
-	// create message and push to mq
-	if removedQuestion != nil {
-		ctx.Notifier.PublishMessage(&notifier.Message{
-			Type:    notifier.QuestionDelete,
-			Content: removedQuestion,
-			UserID:  removedQuestion.ID,
-		})
-		return removedQuestion, nil
+	ctx.notify(messageType, q)
+
+	return q, nil
+}
+
+// refreshWaitStats recomputes the statistics the wait of the students in line of a class is estimated
+// from, out of the questions resolved within `model.RecentHelpWindow`, the ones being helped with
+// and the TA/teachers on duty
+func (ctx *Context) refreshWaitStats(class string) error {
+
+	resolved, err := ctx.MongoStore.GetResolvedQuestions(class, time.Now().Add(-model.RecentHelpWindow))
+	if err != nil {
+		return err
 	}
 
-	// question not found
-	return nil, ErrQuestionNotFound
+	queue, err := ctx.SessionStore.GetQueue(class)
+	if err != nil {
+		return err
+	}
+
+	duties, err := ctx.SessionStore.GetDuties(class)
+	if err != nil {
+		return err
+	}
+
+	return ctx.SessionStore.SetWaitStats(class, model.NewWaitStats(resolved, queue.Helping, duties))
+}
+
+// newEvent returns the event a change to a queue made by the TA/teacher with `teacherID`, if any, is recorded as;
+// redis fills in the rest along with the change, see `session.RedisStore.FlushEvents`
+func newEvent(messageType, teacherID string) *model.QueueEvent {
+	return &model.QueueEvent{Type: messageType, TeacherID: teacherID}
+}
+
+// notify hands the events of the changes to the queues over to the event log, then creates a message
+// about a change to a question and pushes it to mq
+func (ctx *Context) notify(messageType string, q *model.Question) {
+
+	if err := ctx.flushEvents(); err != nil {
+		log.Printf("cannot flush the events of the queues: %v", err)
+	}
+
+	ctx.Notifier.PublishMessage(&notifier.Message{
+		Type:    messageType,
+		Content: q,
+		UserID:  q.ID,
+		Class:   q.Class,
+	})
 }
 
 // HttpWriter takes necessary arguments to write back to client.
@@ -458,3 +821,12 @@ func (ctx *Context) authenticate(email, password string) (*model.Teacher, error)
 
 	return teachers[0], nil
 }
+
+// getTeacher returns the TA/teacher of the session of the request.
+func (ctx *Context) getTeacher(r *http.Request) (*model.Teacher, error) {
+	teacher := &model.Teacher{}
+	if _, err := session.GetState(r, ctx.Keys, ctx.SessionStore, &session.State{Interface: teacher}); err != nil {
+		return nil, err
+	}
+	return teacher, nil
+}
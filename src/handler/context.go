@@ -2,22 +2,32 @@ package handler
 
 import (
 	"questionqueue/src/db"
+	"questionqueue/src/mail"
 	"questionqueue/src/notifier"
 	"questionqueue/src/session"
 	"questionqueue/src/trie"
 )
 
 type Context struct {
-	Key          string
+	Keys         *session.SigningKeys
 	SessionStore *session.RedisStore
 	MongoStore   *db.MongoStore
 	Trie         *trie.Trie
 	Notifier     *notifier.Notifier
+	// the page students join the queue of a class from; join links add the class and code to it
+	JoinURL string
+	// emails of the TA/teachers who are always admins, see `EnsureAdmins`
+	AdminEmails []string
+	// sends the password reset and verify-email links to TA/teachers
+	Mailer mail.Sender
+	// the pages TA/teachers reset their password and verify their email from; links add the token to them
+	ResetURL  string
+	VerifyURL string
 }
 
-func NewContext(key string, redis *session.RedisStore, mongo *db.MongoStore, trie *trie.Trie, notifier *notifier.Notifier) *Context {
+func NewContext(keys *session.SigningKeys, redis *session.RedisStore, mongo *db.MongoStore, trie *trie.Trie, notifier *notifier.Notifier) *Context {
 	return &Context{
-		Key:          key,
+		Keys:         keys,
 		SessionStore: redis,
 		MongoStore:   mongo,
 		Trie:         trie,
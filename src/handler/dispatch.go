@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/notifier"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DutyHandler lists the TA/teachers on duty in the queue of a class to a TA/teacher,
+// and lets them go on duty covering some topics of the class, or off duty.
+func (ctx *Context) DutyHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the TA/teachers on duty
+	case http.MethodGet:
+
+		duties, err := ctx.SessionStore.GetDuties(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(duties)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// go on duty covering the topics given, all of them if none
+	case http.MethodPut:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		duty, err := decodeDuty(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		// topics come from the class
+		if len(duty.Topics) != 0 {
+			c, err := ctx.MongoStore.GetOneClass(class)
+			if err != nil {
+				http.Error(w, "cannot find the topics of the class: "+err.Error(), http.StatusNotFound)
+				return
+			}
+			if err := duty.ValidateTopics(c); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		duty.TeacherID = teacher.ID.Hex()
+		duty.Since = time.Now()
+		if err := ctx.SessionStore.SetDuty(class, duty); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// the TA/teachers on duty count towards the estimated wait
+		if err := ctx.refreshWaitStats(class); err != nil {
+			log.Printf("cannot refresh the wait statistics of %v: %v", class, err)
+		}
+
+		b, _ := json.Marshal(duty)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// go off duty
+	case http.MethodDelete:
+
+		if err := ctx.SessionStore.RemoveDuty(class, teacher.ID.Hex()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := ctx.refreshWaitStats(class); err != nil {
+			log.Printf("cannot refresh the wait statistics of %v: %v", class, err)
+		}
+
+		httpWriter(http.StatusOK, []byte("off duty"), MimePlain, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// DispatchHandler returns to a TA/teacher how the students waiting in line of a class are handed out
+// to the TA/teachers asking for their next one, and lets them change it; see `model.Dispatch`.
+func (ctx *Context) DispatchHandler(w http.ResponseWriter, r *http.Request) {
+
+	if _, err := ctx.getTeacher(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the dispatch of the queue
+	case http.MethodGet:
+
+		dispatch, err := ctx.SessionStore.GetDispatch(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(dispatch)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// set the dispatch of the queue
+	case http.MethodPut:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		dispatch, err := decodeDispatch(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if err := dispatch.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		dispatch.Class = class
+		if err := ctx.SessionStore.SetDispatch(dispatch); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(dispatch)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// NextQuestionHandler claims the student a TA/teacher should help next in the queue of a class
+// for them, by the topics they cover and, in proximity mode, where they are in the lab given as
+// `x` and `y` in the query, and returns the claimed question; see `model.Dispatch.Next`.
+func (ctx *Context) NextQuestionHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var location *model.Point
+	if x, y := r.URL.Query().Get("x"), r.URL.Query().Get("y"); len(x) != 0 || len(y) != 0 {
+		location = &model.Point{}
+		var errX, errY error
+		location.X, errX = strconv.ParseFloat(x, 64)
+		location.Y, errY = strconv.ParseFloat(y, 64)
+		if errX != nil || errY != nil {
+			http.Error(w, "invalid location", http.StatusBadRequest)
+			return
+		}
+	}
+
+	q, err := claimNextQuestion(ctx, class, teacher.ID.Hex(), location)
+	if err == ErrQuestionNotFound {
+		http.Error(w, "nobody is waiting in line", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(q)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// claimNextQuestion claims the student the TA/teacher with `teacherID` should help next in the queue of a class
+// for them; the TA/teacher is at `location` if known, and where the student is once on duty.
+func claimNextQuestion(ctx *Context, class, teacherID string, location *model.Point) (*model.Question, error) {
+
+	duty, err := ctx.SessionStore.GetDuty(class, teacherID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a TA/teacher off duty covers every topic
+	picker := duty
+	if picker == nil {
+		picker = &model.Duty{TeacherID: teacherID}
+	}
+	if location != nil {
+		picker.Location = location
+	}
+
+	claimed, err := claimQuestions(ctx, class, teacherID, notifier.QuestionClaimed, func(dispatch *model.Dispatch, queue *model.QuestionQueue, at time.Time) ([]*model.Question, []*model.Question, error) {
+		next, skipped := dispatch.Next(queue, picker, at)
+		if next == nil {
+			return nil, nil, ErrQuestionNotFound
+		}
+		return []*model.Question{next}, skipped, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := claimed[0]
+	if duty != nil {
+		duty.Location = &model.Point{X: q.Loc_X, Y: q.Loc_Y}
+		if err := ctx.SessionStore.SetDuty(class, duty); err != nil {
+			log.Printf("cannot move TA/teacher %v to student %v in %v: %v", teacherID, q.ID, class, err)
+		}
+	}
+	return q, nil
+}
+
+// claimQuestion claims the student of `id` waiting in line of a class for the TA/teacher with `teacherID`,
+// who passes over the students ahead of them; see `model.Dispatch.Claim`.
+func claimQuestion(ctx *Context, class, id, teacherID string) (*model.Question, error) {
+	claimed, err := claimQuestions(ctx, class, teacherID, notifier.QuestionClaimed, func(dispatch *model.Dispatch, queue *model.QuestionQueue, at time.Time) ([]*model.Question, []*model.Question, error) {
+		claimed, skipped, err := dispatch.Claim(queue, []string{id}, at)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(claimed) == 0 {
+			return nil, nil, notWaiting(queue, id)
+		}
+		return claimed, skipped, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return claimed[0], nil
+}
+
+// notWaiting returns why the student of `id` cannot be claimed in a queue they are not waiting in line of.
+func notWaiting(queue *model.QuestionQueue, id string) error {
+	for _, q := range queue.Helping {
+		if q.ID == id {
+			return model.ErrInvalidTransition
+		}
+	}
+	return ErrQuestionNotFound
+}
+
+// claimQuestions is how every claim of the queue of a class goes: it atomically claims the questions `pick`
+// chooses under the dispatch of the class for the TA/teacher with `teacherID`, applying `before` to each of them
+// first, if given, and counts every student they pass over in the same step, so that no claim passes over a student
+// who has to be next; see `session.RedisStore.ClaimQuestions`. Every question claimed is notified with a message
+// of type `messageType`, and every student passed over has their skip recorded and is notified
+func claimQuestions(ctx *Context, class, teacherID, messageType string, pick func(*model.Dispatch, *model.QuestionQueue, time.Time) ([]*model.Question, []*model.Question, error), before func(*model.Question) error) ([]*model.Question, error) {
+
+	dispatch, err := ctx.SessionStore.GetDispatch(class)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claimed, skipped, err := ctx.SessionStore.ClaimQuestions(class, func(queue *model.QuestionQueue) ([]*model.Question, []*model.Question, error) {
+		return pick(dispatch, queue, now)
+	}, func(q *model.Question) error {
+		if before != nil {
+			if err := before(q); err != nil {
+				return err
+			}
+		}
+		return q.Transition(model.StatusClaimed, teacherID, now)
+	}, now, newEvent(messageType, teacherID), newEvent(notifier.QuestionSkipped, teacherID))
+	if err != nil {
+		return nil, err
+	}
+
+	// every student passed over is ahead of the last one claimed
+	recordSkips(ctx, dispatch, skipped, claimed[len(claimed)-1], teacherID, now)
+
+	// who is helping changed; the estimated wait goes out with the notification
+	if err := ctx.refreshWaitStats(class); err != nil {
+		log.Printf("cannot refresh the wait statistics of %v: %v", class, err)
+	}
+
+	for _, q := range claimed {
+		ctx.notify(messageType, q)
+	}
+
+	return claimed, nil
+}
+
+// recordSkips records that the TA/teacher with `teacherID` passed over the students of `skipped`, already counted,
+// to help the student of `helped` at a given time, and notifies the MessageQueue; the students keep their place in line.
+func recordSkips(ctx *Context, dispatch *model.Dispatch, skipped []*model.Question, helped *model.Question, teacherID string, at time.Time) {
+	for _, q := range skipped {
+		skip := &model.Skip{
+			Class:      q.Class,
+			StudentID:  q.ID,
+			QuestionID: q.QuestionID,
+			TeacherID:  teacherID,
+			HelpedID:   helped.ID,
+			Mode:       dispatch.Mode,
+			Skips:      q.Skips,
+			At:         at,
+		}
+		if _, err := ctx.MongoStore.InsertSkip(skip); err != nil {
+			log.Printf("cannot record the skip of student %v in %v: %v", q.ID, q.Class, err)
+		}
+
+		ctx.notify(notifier.QuestionSkipped, q)
+	}
+}
+
+// SkipHandler returns to a TA/teacher the students of a class passed over by a TA/teacher helping someone
+// behind them in line within the RFC 3339 query parameters [`from`, `to`], the last day by default.
+func (ctx *Context) SkipHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := ctx.getTeacher(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseTimeParam(r, "to", time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseTimeParam(r, "from", to.Add(-24*time.Hour))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	skips, err := ctx.MongoStore.GetSkips(class, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(skips)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
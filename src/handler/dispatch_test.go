@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveDispatch sends a request with a JSON body to `DispatchHandler` for the queue of a class.
+func serveDispatch(ctx *Context, method, class, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/v1/queue/"+class+"/dispatch", strings.NewReader(body))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", MimeJson)
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/queue/{class}/dispatch", ctx.DispatchHandler)
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestDispatchHandler(t *testing.T) {
+	ctx := newTestContext()
+	if err := ctx.SessionStore.Client.Ping().Err(); err != nil {
+		t.Skipf("redis is not available at %v: %v", redisAddr, err)
+	}
+
+	teacher := model.Teacher{ID: primitive.NewObjectID(), Role: model.RoleTA}
+	signIn := httptest.NewRecorder()
+	state := session.State{SessionStart: time.Now(), Interface: teacher}
+	if _, err := session.BeginSession(ctx.Keys, ctx.SessionStore, state, signIn); err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"Authorization": {signIn.Header().Get("Authorization")}}
+
+	// leave the dispatch of the class as the test found it
+	class := "343"
+	previous, err := ctx.SessionStore.GetDispatch(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ctx.SessionStore.SetDispatch(previous) })
+
+	cases := []struct {
+		name     string
+		body     string
+		expected int
+		fallback int
+	}{
+		{"Fallback left out", `{"mode": "in-line"}`, http.StatusOK, model.DefaultFallbackMinutes},
+		{"Fallback set", `{"mode": "in-line", "fallback_minutes": 30}`, http.StatusOK, 30},
+		{"Negative fallback", `{"fallback_minutes": -1}`, http.StatusBadRequest, 0},
+		{"Fallback too long", `{"fallback_minutes": 1000000000}`, http.StatusBadRequest, 0},
+	}
+
+	for _, c := range cases {
+		w := serveDispatch(ctx, http.MethodPut, class, c.body, header)
+		if w.Code != c.expected {
+			t.Errorf("%v: expected %v, got %v: %v", c.name, c.expected, w.Code, w.Body.String())
+			continue
+		}
+		if c.expected != http.StatusOK {
+			continue
+		}
+
+		dispatch, err := ctx.SessionStore.GetDispatch(class)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dispatch.FallbackMinutes != c.fallback {
+			t.Errorf("%v: expected a fallback of %v minutes, got %v", c.name, c.fallback, dispatch.FallbackMinutes)
+		}
+
+		var returned model.Dispatch
+		if err := json.Unmarshal(w.Body.Bytes(), &returned); err != nil || returned.FallbackMinutes != c.fallback {
+			t.Errorf("%v: expected a fallback of %v minutes in the response, got %+v and %v", c.name, c.fallback, returned, err)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/notifier"
+	"strings"
+	"time"
+)
+
+// groupActions maps the endpoints of `GroupActionHandler` to the status they change the questions of a group to.
+var groupActions = map[string]string{
+	"start":   model.StatusInProgress,
+	"resolve": model.StatusResolved,
+	"release": model.StatusWaiting,
+}
+
+// GroupHandler suggests to a TA/teacher groups of students waiting in line of a class with questions
+// on the same topic, and lets them claim several students to help together as a group.
+func (ctx *Context) GroupHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the suggested groups
+	case http.MethodGet:
+
+		queue, err := ctx.SessionStore.GetQueue(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(model.SuggestGroups(queue, time.Now()))
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// claim the students given as a group, or the suggested group of the topic given
+	case http.MethodPost:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		ng, err := decodeHelpGroup(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if len(ng.Members) == 0 && len(ng.Topic) != 0 {
+			queue, err := ctx.SessionStore.GetQueue(class)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, s := range model.SuggestGroups(queue, time.Now()) {
+				if s.Topic == ng.Topic {
+					ng.Members = s.Members
+				}
+			}
+		}
+
+		if err := ng.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		group, err := claimGroup(ctx, class, teacher.ID.Hex(), ng)
+		if err == ErrQuestionNotFound {
+			http.Error(w, "none of the students can be claimed", http.StatusConflict)
+			return
+		} else if err == model.ErrOverdueAhead {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(group)
+		httpWriter(http.StatusCreated, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// GroupActionHandler lets the TA/teacher helping a group of a class start helping, resolve or release
+// every student still in the group at once; see `groupActions`.
+func (ctx *Context) GroupActionHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if !model.ValidateClass(vars["class"]) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status, ok := groupActions[vars["action"]]
+	if !ok {
+		http.Error(w, ErrUnknownAction.Error(), http.StatusNotFound)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(vars["group_id"])
+	if err != nil {
+		http.Error(w, model.ErrGroupNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	group, err := ctx.MongoStore.GetHelpGroup(vars["class"], id)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, model.ErrGroupNotFound.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if group.TeacherID != teacher.ID.Hex() {
+		http.Error(w, model.ErrClaimedByOther.Error(), http.StatusConflict)
+		return
+	}
+
+	if err := transitionGroup(ctx, group, status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(group)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// claimGroup claims the students of `ng` that are still waiting in line of a class for the TA/teacher
+// with `teacherID` as one group, which it records; every member is notified where the group meets.
+// It returns `ErrQuestionNotFound` if none of them could be claimed, or `model.ErrOverdueAhead` if the group
+// would pass over a student who has to be next; see `claimQuestions`
+func claimGroup(ctx *Context, class, teacherID string, ng *model.HelpGroup) (*model.HelpGroup, error) {
+
+	group := &model.HelpGroup{
+		ID:        primitive.NewObjectID(),
+		Class:     class,
+		Topic:     ng.Topic,
+		TeacherID: teacherID,
+		Location:  ng.Location,
+		Members:   []string{},
+		Status:    model.StatusClaimed,
+		ClaimedAt: time.Now(),
+	}
+
+	claimed, err := claimQuestions(ctx, class, teacherID, notifier.QuestionGrouped, func(dispatch *model.Dispatch, queue *model.QuestionQueue, at time.Time) ([]*model.Question, []*model.Question, error) {
+		claimed, skipped, err := dispatch.Claim(queue, ng.Members, at)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(claimed) == 0 {
+			// the students left or got claimed in the meantime
+			return nil, nil, ErrQuestionNotFound
+		}
+		return claimed, skipped, nil
+	}, func(q *model.Question) error {
+		q.JoinGroup(group)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range claimed {
+		group.Members = append(group.Members, q.ID)
+		if len(group.Topic) == 0 {
+			group.Topic = q.Topic
+		}
+	}
+
+	if _, err := ctx.MongoStore.InsertHelpGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// transitionGroup changes the status of the questions of every student still in a group and records
+// the group with the students it still has; a resolved or released group is over
+func transitionGroup(ctx *Context, group *model.HelpGroup, status string) error {
+
+	members := []string{}
+	for _, id := range group.Members {
+		_, err := transitionMember(ctx, group, id, status)
+		switch err {
+		case nil:
+			members = append(members, id)
+		case ErrQuestionNotFound, model.ErrNotInGroup, model.ErrInvalidTransition:
+			// the student left the group in the meantime
+			continue
+		default:
+			return err
+		}
+	}
+
+	group.Members = members
+	group.Status = status
+	if status == model.StatusResolved || status == model.StatusWaiting {
+		group.ResolvedAt = time.Now()
+	}
+
+	if _, err := ctx.MongoStore.UpdateHelpGroup(group); err != nil {
+		log.Printf("cannot record group %v of %v: %v", group.ID.Hex(), group.Class, err)
+	}
+	return nil
+}
+
+// transitionMember changes the status of the question of a student if they are still in the group
+func transitionMember(ctx *Context, group *model.HelpGroup, id, status string) (*model.Question, error) {
+	return changeStatus(ctx, group.Class, id, status, group.TeacherID, statusMessageTypes[status], func(q *model.Question) error {
+		if q.GroupID != group.ID {
+			return model.ErrNotInGroup
+		}
+		return nil
+	})
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"time"
+)
+
+// QueueHistory is the queue of a class at a point in time, along with
+// the events that happened since a given time up to then.
+type QueueHistory struct {
+	At     time.Time            `json:"at"`
+	Queue  *model.QuestionQueue `json:"queue"`
+	Events []*model.QueueEvent  `json:"events"`
+}
+
+// QueueHistoryHandler lets a TA/teacher reconstruct the queue of a class at the time given as
+// RFC 3339 query parameter `at`, now if not given, by replaying its event log over `model.HistoryWindow`;
+// the events since the time given as query parameter `from` within the window, if any, are returned along with it.
+func (ctx *Context) QueueHistoryHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// current state discarded
+	_, err := session.GetState(r, ctx.Keys, ctx.SessionStore, &session.State{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	at, err := parseTimeParam(r, "at", time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseTimeParam(r, "from", at)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	since := at.Add(-model.HistoryWindow)
+	if from.Before(since) {
+		http.Error(w, "from is more than a day before at", http.StatusBadRequest)
+		return
+	}
+
+	events, err := ctx.getEvents(class, since, at)
+	if err == model.ErrTooManyEvents {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	history := &QueueHistory{
+		At:     at,
+		Queue:  model.ReplayQueue(class, events, at),
+		Events: []*model.QueueEvent{},
+	}
+	for _, e := range events {
+		if !e.At.Before(from) {
+			history.Events = append(history.Events, e)
+		}
+	}
+
+	b, _ := json.Marshal(history)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// FairnessHandler returns to a TA/teacher every time a student of a class was helped while someone who lined up
+// earlier was still waiting, between the RFC 3339 query parameters `from` and `to`, the last day by default
+// and at most `model.MaxFairnessRange`; see `model.NewFairnessReport`.
+func (ctx *Context) FairnessHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := ctx.getTeacher(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseTimeParam(r, "to", time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseTimeParam(r, "from", to.Add(-24*time.Hour))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if to.Before(from) {
+		http.Error(w, "from is after to", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > model.MaxFairnessRange {
+		http.Error(w, "from is more than a week before to", http.StatusBadRequest)
+		return
+	}
+
+	// from a window before, for everyone already waiting at `from`
+	events, err := ctx.getEvents(class, from.Add(-model.HistoryWindow), to)
+	if err == model.ErrTooManyEvents {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(model.NewFairnessReport(class, events, from, to))
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// FlushEvents hands the events of the changes to the queues over from redis to the event log in mongo
+// every `interval`, so that the ones mongo could not take right away still get logged; it never returns.
+func (ctx *Context) FlushEvents(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := ctx.flushEvents(); err != nil {
+			log.Printf("cannot flush the events of the queues: %v", err)
+		}
+	}
+}
+
+// flushEvents hands the events recorded in redis over to the event log, see `session.RedisStore.FlushEvents`.
+func (ctx *Context) flushEvents() error {
+	return ctx.SessionStore.FlushEvents(func(e *model.QueueEvent) error {
+		_, err := ctx.MongoStore.InsertEvent(e)
+		return err
+	})
+}
+
+// getEvents returns the events of the queue of a class within [since, to] to replay, once every event
+// recorded in redis is in the event log, after the last event before them that set its ordering policy, if any;
+// it returns `model.ErrTooManyEvents` for more than `model.MaxHistoryEvents`.
+func (ctx *Context) getEvents(class string, since, to time.Time) ([]*model.QueueEvent, error) {
+	if err := ctx.flushEvents(); err != nil {
+		return nil, err
+	}
+
+	events, err := ctx.MongoStore.GetEvents(class, since, to, model.MaxHistoryEvents+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > model.MaxHistoryEvents {
+		return nil, model.ErrTooManyEvents
+	}
+
+	policy, err := ctx.MongoStore.GetPolicyEvent(class, since)
+	if err != nil {
+		return nil, err
+	} else if policy != nil {
+		events = append([]*model.QueueEvent{policy}, events...)
+	}
+	return events, nil
+}
+
+// parseTimeParam parses the RFC 3339 time in a query parameter, or returns `def` if it is not given.
+func parseTimeParam(r *http.Request, name string, def time.Time) (time.Time, error) {
+	param := r.URL.Query().Get(name)
+	if len(param) == 0 {
+		return def, nil
+	}
+	return time.Parse(time.RFC3339, param)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"testing"
+	"time"
+)
+
+func TestHistoryHandlers_Range(t *testing.T) {
+	ctx := newTestContext()
+	if err := ctx.SessionStore.Client.Ping().Err(); err != nil {
+		t.Skipf("redis is not available at %v: %v", redisAddr, err)
+	}
+
+	teacher := model.Teacher{ID: primitive.NewObjectID(), Role: model.RoleTA}
+	signIn := httptest.NewRecorder()
+	state := session.State{SessionStart: time.Now(), Interface: teacher}
+	if _, err := session.BeginSession(ctx.Keys, ctx.SessionStore, state, signIn); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/queue/{class}/history", ctx.QueueHistoryHandler)
+	router.HandleFunc("/v1/queue/{class}/fairness", ctx.FairnessHandler)
+
+	// the event log is only read over a bounded range
+	at := time.Date(2019, time.June, 3, 14, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		path string
+	}{
+		{"History from more than a day before", "/v1/queue/343/history?at=" + at.Format(time.RFC3339) + "&from=" + at.Add(-model.HistoryWindow-time.Minute).Format(time.RFC3339)},
+		{"Fairness over more than a week", "/v1/queue/343/fairness?to=" + at.Format(time.RFC3339) + "&from=" + at.Add(-model.MaxFairnessRange-time.Minute).Format(time.RFC3339)},
+		{"Fairness from after to", "/v1/queue/343/fairness?to=" + at.Format(time.RFC3339) + "&from=" + at.Add(time.Minute).Format(time.RFC3339)},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, c.path, nil)
+		r.Header.Set("Authorization", signIn.Header().Get("Authorization"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%v: expected %v, got %v: %v", c.name, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"strings"
+	"time"
+)
+
+// InviteHandler lets instructors and admins invite a TA/teacher to register, and admins list the pending invites.
+// Instructors can only invite TAs, to no class or to a class they are an instructor of.
+func (ctx *Context) InviteHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	// get the pending invites
+	case http.MethodGet:
+
+		if teacher.AccountRole() != model.RoleAdmin {
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+
+		invites, err := ctx.MongoStore.GetPendingInvites(time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(invites)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// invite a TA/teacher; the token is only ever in this response
+	case http.MethodPost:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		ni, err := decodeInvite(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		invite, err := model.NewInvite(ni, teacher.ID.Hex(), teacher.AccountRole(), time.Now())
+		if err == model.ErrInviteRole {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(invite.Class) != 0 {
+			role, err := ctx.classRole(teacher, invite.Class)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if !model.HasRole(role, model.RoleInstructor) {
+				http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		if _, err := ctx.MongoStore.InsertInvite(invite.Invite); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(invite)
+		httpWriter(http.StatusCreated, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// SpecificInviteHandler lets admins revoke a pending invite.
+func (ctx *Context) SpecificInviteHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(mux.Vars(r)["invite_id"])
+	if err != nil {
+		http.Error(w, ErrInviteNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	res, err := ctx.MongoStore.RevokeInvite(id, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res.MatchedCount == 0 {
+		http.Error(w, ErrInviteNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	httpWriter(http.StatusOK, []byte("invite revoked"), MimePlain, w)
+}
+
+// useInvite marks the invite a new TA/teacher registers with as used and returns it, or returns
+// `model.ErrInvalidInvite` if it cannot be used; admins by `Context.AdminEmails` need none, but only get
+// their role once they verified their email.
+func (ctx *Context) useInvite(nt *model.NewTeacher) (*model.Invite, error) {
+	if len(nt.Invite) == 0 {
+		if ctx.isAdminEmail(nt.Email) {
+			return nil, nil
+		}
+		return nil, model.ErrInvalidInvite
+	}
+
+	invite, err := ctx.MongoStore.UseInvite(model.HashInviteToken(nt.Invite), nt.Email, time.Now())
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrInvalidInvite
+	} else if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// releaseInvite makes an invite used to register pending again, when registering failed.
+func (ctx *Context) releaseInvite(invite *model.Invite) {
+	if invite == nil {
+		return
+	}
+	if _, err := ctx.MongoStore.ReleaseInvite(invite.ID); err != nil {
+		log.Printf("cannot release invite %v: %v", invite.ID.Hex(), err)
+	}
+}
+
+// joinInvitedStaff adds a TA/teacher who just registered to the staff of the class they were invited to.
+func (ctx *Context) joinInvitedStaff(invite *model.Invite, teacherID primitive.ObjectID) {
+	staff := &model.Staff{
+		Class:     invite.Class,
+		TeacherID: teacherID.Hex(),
+		Role:      invite.StaffRole(),
+		AddedBy:   invite.CreatedBy,
+		AddedAt:   time.Now(),
+	}
+	if _, err := ctx.MongoStore.SetStaff(staff); err != nil {
+		log.Printf("cannot add %v to the staff of %v: %v", staff.TeacherID, staff.Class, err)
+	}
+}
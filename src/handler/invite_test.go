@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTeacherHandler_RegisterWithoutInvite(t *testing.T) {
+	ctx := newTestContext()
+	ctx.AdminEmails = []string{"admin@uw.edu"}
+
+	body := `{"email":"ta@uw.edu","password":"password","password_conf":"password","first_name":"T","last_name":"A"}`
+	r := httptest.NewRequest(http.MethodPost, "/v1/teacher", strings.NewReader(body))
+	r.Header.Set("Content-Type", MimeJson)
+	w := httptest.NewRecorder()
+	ctx.TeacherHandler(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected %v, got %v", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestInviteHandler_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/invite", nil)
+	w := httptest.NewRecorder()
+	ctx.InviteHandler(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %v, got %v", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/url"
+	"questionqueue/src/model"
+	"questionqueue/src/qrcode"
+	"strings"
+	"time"
+)
+
+// pixels per module of the QR codes of join links, big enough to read off a projector
+const joinQRScale = 12
+
+// JoinCodeHandler returns to a TA/teacher the current join code of a class and the link to join with it,
+// making the first join settings of the class if it has none; students need the code to line up.
+// A TA/teacher can change how often the code changes, which also makes the codes given so far stop working.
+func (ctx *Context) JoinCodeHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the current join code
+	case http.MethodGet:
+
+		settings, err := getJoinSettings(ctx, class, teacher.ID.Hex())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(ctx.joinCode(settings, time.Now()))
+		w.Header().Set("Cache-Control", "no-store")
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// make new join settings rotating codes as often as given
+	case http.MethodPut:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		ns, err := decodeJoinSettings(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		settings, err := model.NewJoinSettings(class, teacher.ID.Hex(), ns.RotationMinutes)
+		if err == model.ErrInvalidJoinSettings {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := ctx.SessionStore.SetJoinSettings(settings); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(ctx.joinCode(settings, time.Now()))
+		w.Header().Set("Cache-Control", "no-store")
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// JoinQRHandler returns to a TA/teacher a PNG QR code of the current join link of a class, to project in the lab;
+// it expires along with the code, so a page showing it should load it again then.
+func (ctx *Context) JoinQRHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settings, err := getJoinSettings(ctx, class, teacher.ID.Hex())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	code := ctx.joinCode(settings, time.Now())
+	qr, err := qrcode.Encode(code.Link)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := qr.WritePNG(&buf, joinQRScale); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Expires", code.ExpiresAt.UTC().Format(http.TimeFormat))
+	httpWriter(http.StatusOK, buf.Bytes(), MimePng, w)
+}
+
+// getJoinSettings returns the join settings of a class, making and saving default ones
+// for the TA/teacher with `teacherID` if the class has none yet; another TA/teacher may do it first.
+func getJoinSettings(ctx *Context, class, teacherID string) (*model.JoinSettings, error) {
+	settings, err := ctx.SessionStore.GetJoinSettings(class)
+	if err != nil || settings != nil {
+		return settings, err
+	}
+
+	settings, err = model.NewJoinSettings(class, teacherID, model.DefaultJoinRotationMinutes)
+	if err != nil {
+		return nil, err
+	}
+	if added, err := ctx.SessionStore.AddJoinSettings(settings); err != nil {
+		return nil, err
+	} else if !added {
+		return ctx.SessionStore.GetJoinSettings(class)
+	}
+	return settings, nil
+}
+
+// verifyJoinCode checks the join code a student lines up in the queue of a class with;
+// a class that never had a join code made has no valid code.
+func verifyJoinCode(ctx *Context, class, code string) error {
+	if len(code) == 0 {
+		return model.ErrInvalidJoinCode
+	}
+
+	settings, err := ctx.SessionStore.GetJoinSettings(class)
+	if err != nil {
+		return err
+	}
+	if settings == nil || !settings.Verify(code, time.Now()) {
+		return model.ErrInvalidJoinCode
+	}
+	return nil
+}
+
+// joinCode returns the join code of a class at a given time along with its link, see `Context.JoinURL`.
+func (ctx *Context) joinCode(settings *model.JoinSettings, at time.Time) *model.JoinCode {
+	code := settings.Code(at)
+
+	query := url.Values{}
+	query.Set("class", code.Class)
+	query.Set("code", code.Code)
+	code.Link = ctx.JoinURL + "?" + query.Encode()
+	return code
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"questionqueue/src/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postQuestion sends a question of a student in the queue of a class, lining up with a join code, to `PostQuestionHandler`.
+func postQuestion(ctx *Context, class, code string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"id": "student", "class": %q, "topic": "recursion"}`, class)
+	r := httptest.NewRequest(http.MethodPost, "/v1/student?code="+code, strings.NewReader(body))
+	r.Header.Set("Content-Type", MimeJson)
+	w := httptest.NewRecorder()
+	ctx.PostQuestionHandler(w, r)
+	return w
+}
+
+func TestPostQuestionHandler_NoJoinCode(t *testing.T) {
+	if w := postQuestion(newTestContext(), "343", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected %v, got %v: %v", http.StatusForbidden, w.Code, w.Body.String())
+	}
+}
+
+func TestPostQuestionHandler_InvalidJoinCode(t *testing.T) {
+	ctx := newTestContext()
+	if err := ctx.SessionStore.Client.Ping().Err(); err != nil {
+		t.Skipf("redis is not available at %v: %v", redisAddr, err)
+	}
+
+	class := "343"
+	clearQueue(t, ctx, class)
+	expectInvalidCode := func(name string, w *httptest.ResponseRecorder) {
+		if w.Code != http.StatusForbidden || strings.TrimSpace(w.Body.String()) != model.ErrInvalidJoinCode.Error() {
+			t.Errorf("%v: expected %v with %q, got %v: %v", name, http.StatusForbidden, model.ErrInvalidJoinCode, w.Code, w.Body.String())
+		}
+	}
+
+	expectInvalidCode("class without join code", postQuestion(ctx, class, "ABCDEF"))
+
+	settings, err := model.NewJoinSettings(class, "teacher", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.SessionStore.SetJoinSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	expired := settings.Code(time.Now().Add(-10 * time.Minute)).Code
+	if settings.Verify(expired, time.Now()) {
+		t.Skip("the expired code happens to be the current one")
+	}
+	expectInvalidCode("expired join code", postQuestion(ctx, class, expired))
+	expectInvalidCode("made up join code", postQuestion(ctx, class, "ZZZZZZ"))
+
+	if _, err := ctx.SessionStore.GetPosition(class, "student"); err != ErrQuestionNotFound {
+		t.Errorf("expected the student not to line up, got %v", err)
+	}
+}
+
+func TestJoinCodeHandler_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	for _, path := range []string{"/v1/queue/343/join", "/v1/queue/343/join/qr"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		if strings.HasSuffix(path, "/qr") {
+			ctx.JoinQRHandler(w, r)
+		} else {
+			ctx.JoinCodeHandler(w, r)
+		}
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%v: expected %v, got %v: %v", path, http.StatusUnauthorized, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestContext_JoinCode(t *testing.T) {
+	ctx := &Context{JoinURL: "https://queue.example.com/join"}
+	settings, err := model.NewJoinSettings("343", "teacher", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := ctx.joinCode(settings, time.Now())
+	if expected := "https://queue.example.com/join?class=343&code=" + code.Code; code.Link != expected {
+		t.Errorf("expected link %v, got %v", expected, code.Link)
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"net/http"
+	"questionqueue/src/model"
+	"strings"
+	"time"
+)
+
+// LabSessionHandler returns the lab sessions of a class to a TA/teacher, most recent first,
+// and lets a TA/teacher open a new one with the room and the IDs of the other TA/teachers on duty.
+func (ctx *Context) LabSessionHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the lab sessions of the class
+	case http.MethodGet:
+
+		labs, err := ctx.MongoStore.GetLabSessions(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(labs)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// open a lab session of the class
+	case http.MethodPost:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		nl, err := decodeLabSession(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		lab, err := openLabSession(ctx, class, nl.Room, append(nl.Staff, teacher.ID.Hex()))
+		if err == model.ErrLabSessionOpen {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(lab)
+		httpWriter(http.StatusCreated, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// CloseLabSessionHandler lets a TA/teacher close the open lab session of a class
+// and returns it with the summary of its questions.
+func (ctx *Context) CloseLabSessionHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := ctx.getTeacher(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lab, err := closeLabSession(ctx, class)
+	if err == model.ErrLabSessionNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(lab)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// openLabSession opens a lab session of a class in a room with the TA/teachers on duty,
+// unless the class already has one open
+func openLabSession(ctx *Context, class, room string, staff []string) (*model.LabSession, error) {
+
+	if _, err := ctx.MongoStore.GetOpenLabSession(class); err == nil {
+		return nil, model.ErrLabSessionOpen
+	} else if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// every TA/teacher on duty once
+	seen := make(map[string]bool)
+	onDuty := []string{}
+	for _, id := range staff {
+		if len(id) != 0 && !seen[id] {
+			seen[id] = true
+			onDuty = append(onDuty, id)
+		}
+	}
+
+	lab := &model.LabSession{
+		ID:       primitive.NewObjectID(),
+		Class:    class,
+		Room:     room,
+		Staff:    onDuty,
+		OpenedAt: time.Now(),
+	}
+	if _, err := ctx.MongoStore.InsertLabSession(lab); err != nil {
+		return nil, err
+	}
+	return lab, nil
+}
+
+// closeLabSession closes the open lab session of a class and sums up the questions asked in it
+func closeLabSession(ctx *Context, class string) (*model.LabSession, error) {
+
+	lab, err := ctx.MongoStore.GetOpenLabSession(class)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrLabSessionNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	questions, err := ctx.MongoStore.GetLabSessionQuestions(lab)
+	if err != nil {
+		return nil, err
+	}
+
+	lab.ClosedAt = time.Now()
+	lab.Summary = model.Summarize(questions)
+	if _, err := ctx.MongoStore.CloseLabSession(lab); err != nil {
+		return nil, err
+	}
+	return lab, nil
+}
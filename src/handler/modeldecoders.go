@@ -17,6 +17,16 @@ func decodeQuestion(d io.ReadCloser) (*model.Question, error) {
 	}
 }
 
+func decodeNewQuestion(d io.ReadCloser) (*model.NewQuestion, error) {
+	decoder := json.NewDecoder(d)
+	var i model.NewQuestion
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
 func decodeNewTeacher(d io.ReadCloser) (*model.NewTeacher, error) {
 	decoder := json.NewDecoder(d)
 	var i model.NewTeacher
@@ -56,3 +66,143 @@ func decodeTeacherLogin(d io.ReadCloser) (*model.TeacherLogin, error) {
 		return &i, nil
 	}
 }
+
+func decodeQueueState(d io.ReadCloser) (*model.QueueState, error) {
+	decoder := json.NewDecoder(d)
+	var i model.QueueState
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeSchedule(d io.ReadCloser) (*model.Schedule, error) {
+	decoder := json.NewDecoder(d)
+	var i model.Schedule
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeLabSession(d io.ReadCloser) (*model.LabSession, error) {
+	decoder := json.NewDecoder(d)
+	var i model.LabSession
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodePolicy(d io.ReadCloser) (*model.Policy, error) {
+	decoder := json.NewDecoder(d)
+	var i model.Policy
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeDuty(d io.ReadCloser) (*model.Duty, error) {
+	decoder := json.NewDecoder(d)
+	var i model.Duty
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeDispatch(d io.ReadCloser) (*model.Dispatch, error) {
+	decoder := json.NewDecoder(d)
+	var i model.Dispatch
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeHelpGroup(d io.ReadCloser) (*model.HelpGroup, error) {
+	decoder := json.NewDecoder(d)
+	var i model.HelpGroup
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeJoinSettings(d io.ReadCloser) (*model.JoinSettings, error) {
+	decoder := json.NewDecoder(d)
+	var i model.JoinSettings
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeRoleUpdate(d io.ReadCloser) (*model.RoleUpdate, error) {
+	decoder := json.NewDecoder(d)
+	var i model.RoleUpdate
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeStaff(d io.ReadCloser) (*model.Staff, error) {
+	decoder := json.NewDecoder(d)
+	var i model.Staff
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeInvite(d io.ReadCloser) (*model.Invite, error) {
+	decoder := json.NewDecoder(d)
+	var i model.Invite
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodePasswordResetRequest(d io.ReadCloser) (*model.PasswordResetRequest, error) {
+	decoder := json.NewDecoder(d)
+	var i model.PasswordResetRequest
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodePasswordReset(d io.ReadCloser) (*model.PasswordReset, error) {
+	decoder := json.NewDecoder(d)
+	var i model.PasswordReset
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
+
+func decodeEmailVerification(d io.ReadCloser) (*model.EmailVerification, error) {
+	decoder := json.NewDecoder(d)
+	var i model.EmailVerification
+	if err := decoder.Decode(&i); err != nil {
+		return nil, err
+	} else {
+		return &i, nil
+	}
+}
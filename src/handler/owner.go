@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+)
+
+// OwnerTokenHeader is the header a student sends the token of their question in.
+const OwnerTokenHeader = "X-Question-Token"
+
+var (
+	ErrNoOwnerToken = errors.New("no question token provided")
+	ErrNotOwner     = session.ErrNotOwner
+)
+
+// PostedQuestion is a question that just joined the queue of its class, along with the secret token
+// the student proves they own it with, see `session.NewOwnerToken`. The token is only ever sent
+// in this response; it is neither broadcast nor recorded.
+type PostedQuestion struct {
+	*model.Question
+	Token string `json:"token"`
+}
+
+// verifyOwner checks that the request proves the student with `id` owns their question in the queue
+// of a class with the token in `OwnerTokenHeader`; it returns ErrNoOwnerToken, ErrNotOwner or ErrQuestionNotFound.
+func verifyOwner(ctx *Context, r *http.Request, class, id string) error {
+	token := r.Header.Get(OwnerTokenHeader)
+	if len(token) == 0 {
+		return ErrNoOwnerToken
+	}
+	return ctx.SessionStore.VerifyOwner(class, id, token)
+}
+
+// hasSession reports whether the request comes with a session ID, valid or not, see `session.GetSessionID`.
+func hasSession(r *http.Request) bool {
+	return len(r.Header.Get("Authorization")) != 0 || len(r.URL.Query().Get("auth")) != 0
+}
+
+// ownerErrorStatus returns the status code to answer a request that failed `verifyOwner` with.
+func ownerErrorStatus(err error) int {
+	switch err {
+	case ErrNoOwnerToken:
+		return http.StatusUnauthorized
+	case ErrNotOwner:
+		return http.StatusForbidden
+	case ErrQuestionNotFound:
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"strings"
+	"testing"
+	"time"
+)
+
+// connector
+const redisAddr = "localhost:6379"
+
+// newTestContext returns a context whose redis is not used until a request gets past authorization.
+func newTestContext() *Context {
+	return &Context{Keys: session.NewSigningKeys("test key"), SessionStore: session.NewRedisStore(session.NewRedisClient(redisAddr), time.Hour)}
+}
+
+// clearQueue deletes every redis key of the queue of a class, before the test and once it is done,
+// so that the test starts from an empty queue and leaves nothing behind.
+func clearQueue(t *testing.T, ctx *Context, class string) {
+	clear := func() {
+		keys, err := ctx.SessionStore.Client.Keys("queue:" + class + ":*").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx.SessionStore.Client.Del(append(keys, "queue:"+class)...)
+	}
+	clear()
+	t.Cleanup(clear)
+}
+
+// serveQuestion sends a request for the question of `id` in the queue of a class to `SpecificQuestionHandler`.
+func serveQuestion(ctx *Context, method, class, id, body string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/v1/student/"+id+"?class="+class, strings.NewReader(body))
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/student/{id}", ctx.SpecificQuestionHandler)
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestSpecificQuestionHandler_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	cases := []struct {
+		name   string
+		method string
+		header http.Header
+	}{
+		{"Dequeue without credentials", http.MethodDelete, http.Header{}},
+		{"Dequeue with an invalid session", http.MethodDelete, http.Header{"Authorization": {"Bearer not-a-session"}}},
+		{"Dequeue with an invalid session and a token", http.MethodDelete, http.Header{"Authorization": {"Bearer not-a-session"}, OwnerTokenHeader: {"token"}}},
+		{"Dequeue with an invalid scheme", http.MethodDelete, http.Header{"Authorization": {"Basic abc"}}},
+		{"Update without credentials", http.MethodPatch, http.Header{"Content-Type": {MimeJson}}},
+	}
+
+	for _, c := range cases {
+		w := serveQuestion(ctx, c.method, "343", "student", `{"description": "typo"}`, c.header)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%v: expected %v, got %v: %v", c.name, http.StatusUnauthorized, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestSpecificQuestionHandler_Forbidden(t *testing.T) {
+	ctx := newTestContext()
+	if err := ctx.SessionStore.Client.Ping().Err(); err != nil {
+		t.Skipf("redis is not available at %v: %v", redisAddr, err)
+	}
+
+	class := "343"
+	clearQueue(t, ctx, class)
+	token, err := session.NewOwnerToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &model.Question{ID: "student", Class: class, CreatedAt: time.Now()}
+	if _, err := ctx.SessionStore.EnqueueIfOpen(q, nil, token, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{http.MethodDelete, http.MethodPatch} {
+		header := http.Header{OwnerTokenHeader: {token + "x"}, "Content-Type": {MimeJson}}
+		if w := serveQuestion(ctx, method, class, q.ID, `{"description": "typo"}`, header); w.Code != http.StatusForbidden {
+			t.Errorf("%v with a wrong token: expected %v, got %v: %v", method, http.StatusForbidden, w.Code, w.Body.String())
+		}
+	}
+
+	// someone else's token does not work either
+	header := http.Header{OwnerTokenHeader: {token}}
+	if w := serveQuestion(ctx, http.MethodDelete, class, "someone-else", "", header); w.Code != http.StatusNotFound {
+		t.Errorf("expected %v for a student not in line, got %v: %v", http.StatusNotFound, w.Code, w.Body.String())
+	}
+
+	if _, err := ctx.SessionStore.GetPosition(class, q.ID); err != nil {
+		t.Errorf("expected the student to stay in line, got %v", err)
+	}
+}
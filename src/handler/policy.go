@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/notifier"
+	"strings"
+)
+
+// PolicyHandler returns to a TA/teacher how the students waiting in line of a class are ordered,
+// and lets them change it; see `model.Policy`.
+func (ctx *Context) PolicyHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the policy of the queue
+	case http.MethodGet:
+
+		policy, err := ctx.SessionStore.GetPolicy(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(policy)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// set the policy of the queue
+	case http.MethodPut:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		policy, err := decodePolicy(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if err := policy.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		policy.Class = class
+		// the history of the queue orders the line by the policy in force at the time
+		if err := ctx.SessionStore.SetPolicy(policy, newEvent(notifier.QueuePolicy, teacher.ID.Hex())); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := ctx.flushEvents(); err != nil {
+			log.Printf("cannot flush the events of the queues: %v", err)
+		}
+
+		// everyone's position may have changed
+		ctx.Notifier.PublishMessage(&notifier.Message{
+			Type:    notifier.QueuePolicy,
+			Content: policy,
+			UserID:  teacher.ID.Hex(),
+			Class:   class,
+		})
+
+		b, _ := json.Marshal(policy)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
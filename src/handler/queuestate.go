@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/notifier"
+	"strings"
+	"time"
+)
+
+// QueueRejection tells a student why their question cannot join the queue of a class.
+type QueueRejection struct {
+	Error string            `json:"error"`
+	State *model.QueueState `json:"state"`
+}
+
+// isQueueRejection reports whether an error is the reason the state of a queue gives to reject a new question.
+func isQueueRejection(err error) bool {
+	switch err {
+	case model.ErrQueueClosed, model.ErrQueuePaused, model.ErrQueueFull, model.ErrLastCallPassed:
+		return true
+	}
+	return false
+}
+
+// QueueStateHandler returns whether and how students can join the queue of a class to anyone,
+// and lets a TA/teacher open, pause or close it, limit its length or set a last call.
+func (ctx *Context) QueueStateHandler(w http.ResponseWriter, r *http.Request) {
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the state of the queue
+	case http.MethodGet:
+
+		state, err := ctx.SessionStore.GetQueueState(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(state)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// set the state of the queue
+	case http.MethodPut:
+
+		teacher, err := ctx.getTeacher(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		state, err := decodeQueueState(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if err := state.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		state.Class = class
+		state.UpdatedBy = teacher.ID.Hex()
+		state.UpdatedAt = time.Now()
+
+		if err := setQueueState(ctx, state); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(state)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// setQueueState saves the state of the queue of a class to redis and notifies the MessageQueue
+// so that every websocket client of the class gets it
+func setQueueState(ctx *Context, state *model.QueueState) error {
+
+	if err := ctx.SessionStore.SetQueueState(state); err != nil {
+		return err
+	}
+
+	ctx.Notifier.PublishMessage(&notifier.Message{
+		Type:    notifier.QueueState,
+		Content: state,
+		UserID:  state.UpdatedBy,
+		Class:   state.Class,
+	})
+
+	return nil
+}
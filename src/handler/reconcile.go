@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/notifier"
+	"questionqueue/src/session"
+)
+
+// Reconciliation reports the differences between the queue of a class in redis
+// and the questions in mongo that have not left it.
+type Reconciliation struct {
+	Class string `json:"class"`
+	// active in mongo but missing from redis; put back in line by the time they were asked
+	Restored []*model.Question `json:"restored"`
+	// in redis but not active in mongo; left as they are for a TA/teacher to look at
+	Unknown []*model.Question `json:"unknown"`
+}
+
+// Reconcile rebuilds the redis queue of a class, or of every class if `class` is empty,
+// from the unresolved questions in mongo and reports what differed between the two.
+func (ctx *Context) Reconcile(class string) ([]*Reconciliation, error) {
+
+	active, err := ctx.MongoStore.GetActiveQuestions()
+	if err != nil {
+		return nil, err
+	}
+
+	// questions in the order they were asked, by class
+	questions := make(map[string][]*model.Question)
+	for _, q := range active {
+		if len(class) == 0 || q.Class == class {
+			questions[q.Class] = append(questions[q.Class], q)
+		}
+	}
+
+	classes := []string{class}
+	if len(class) == 0 {
+		if classes, err = ctx.SessionStore.GetQueueClasses(); err != nil {
+			return nil, err
+		}
+		for c := range questions {
+			classes = append(classes, c)
+		}
+	}
+
+	var reconciliations []*Reconciliation
+	done := make(map[string]bool)
+	for _, c := range classes {
+		if done[c] {
+			continue
+		}
+		done[c] = true
+
+		r, err := ctx.reconcileClass(c, questions[c])
+		if err != nil {
+			return nil, err
+		}
+		reconciliations = append(reconciliations, r)
+	}
+	return reconciliations, nil
+}
+
+// reconcileClass puts the active questions of a class missing from its redis queue back in line
+// and reports the questions only redis knows about.
+func (ctx *Context) reconcileClass(class string, active []*model.Question) (*Reconciliation, error) {
+
+	r := &Reconciliation{Class: class, Restored: []*model.Question{}, Unknown: []*model.Question{}}
+
+	activeIDs := make(map[string]bool)
+	for _, q := range active {
+		activeIDs[q.QuestionID.Hex()] = true
+
+		// the score of a question is the time it was asked, so it goes back to its place in line,
+		// owned by the student who asked it
+		_, err := ctx.SessionStore.Restore(q, newEvent(notifier.QuestionNew, ""))
+		if err == session.ErrQuestionExists {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		r.Restored = append(r.Restored, q)
+		ctx.notify(notifier.QuestionNew, q)
+	}
+
+	queue, err := ctx.SessionStore.GetQueue(class)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range append(queue.Helping, queue.Queue...) {
+		if !activeIDs[q.QuestionID.Hex()] {
+			r.Unknown = append(r.Unknown, q)
+		}
+	}
+
+	return r, nil
+}
+
+// ReconcileHandler lets a TA/teacher rebuild the redis queue of a class, given as query parameter `class`,
+// or of every class from mongo and see what differed.
+func (ctx *Context) ReconcileHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// current state discarded
+	_, err := session.GetState(r, ctx.Keys, ctx.SessionStore, &session.State{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := r.URL.Query().Get("class")
+	if len(class) > 0 && !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reconciliations, err := ctx.Reconcile(class)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(reconciliations)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
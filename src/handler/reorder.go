@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log"
+	"math"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/notifier"
+	"strconv"
+	"time"
+)
+
+// QuestionReorderHandler lets a TA/teacher change the place in line of a question in the queue of a class:
+// - `move` puts the student at the 1-based position given as query parameter `position`;
+// - `requeue` sends a student who was not there to the back of the line;
+// - `hold` skips a student who was not there for the number of minutes given as query parameter `minutes`,
+//   keeping their place in line.
+// A question the TA/teacher had claimed goes back to waiting; `requeue` and `hold` count as a no-show of the student.
+func (ctx *Context) QuestionReorderHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if !model.ValidateClass(vars["class"]) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the back of the line by default
+	position := math.MaxInt32
+	var heldUntil time.Time
+
+	switch vars["action"] {
+	case "move":
+		position, err = strconv.Atoi(r.URL.Query().Get("position"))
+		if err != nil || position < 1 {
+			http.Error(w, "position must be a positive number", http.StatusBadRequest)
+			return
+		}
+	case "requeue":
+	case "hold":
+		minutes, err := strconv.Atoi(r.URL.Query().Get("minutes"))
+		if err != nil || minutes < 1 {
+			http.Error(w, "minutes must be a positive number", http.StatusBadRequest)
+			return
+		}
+		heldUntil = time.Now().Add(time.Duration(minutes) * time.Minute)
+	default:
+		http.Error(w, ErrUnknownAction.Error(), http.StatusNotFound)
+		return
+	}
+
+	q, err := reorderQuestion(ctx, vars["class"], vars["id"], teacher.ID.Hex(), vars["action"], position, heldUntil)
+	switch err {
+	case nil:
+	case ErrQuestionNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case model.ErrInvalidTransition, model.ErrClaimedByOther:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(q)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// NoShowHandler returns to a TA/teacher how many times every student of a class was not there
+// when a TA/teacher came to help them, most first.
+func (ctx *Context) NoShowHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, err := ctx.getTeacher(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	noShows, err := ctx.MongoStore.GetNoShows(class)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(noShows)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// reorderQuestion releases a question the TA/teacher with `teacherID` had claimed and either holds it
+// until `heldUntil` in its place, or moves it to `position` in line in the same update, so that nobody
+// can claim it in between; then notifies the MessageQueue
+func reorderQuestion(ctx *Context, class, id, teacherID, action string, position int, heldUntil time.Time) (*model.Question, error) {
+
+	noShow := action != "move"
+
+	release := func(q *model.Question) error {
+		if q.IsHelping() {
+			if err := q.Transition(model.StatusWaiting, teacherID, time.Now()); err != nil {
+				return err
+			}
+		}
+		q.HeldUntil = heldUntil
+		if noShow {
+			q.NoShows++
+		}
+		return nil
+	}
+
+	// held students keep their place in line
+	if !heldUntil.IsZero() {
+		q, err := ctx.SessionStore.UpdateQuestion(class, id, release, newEvent(notifier.QuestionHeld, teacherID))
+		if err != nil {
+			return nil, err
+		}
+		ctx.countNoShow(class, id)
+		ctx.notify(notifier.QuestionHeld, q)
+		return q, nil
+	}
+
+	messageType := notifier.QuestionMoved
+	if noShow {
+		messageType = notifier.QuestionRequeue
+	}
+	q, _, err := ctx.SessionStore.ReorderQuestion(class, id, position, release, newEvent(messageType, teacherID))
+	if err != nil {
+		return nil, err
+	}
+
+	if noShow {
+		ctx.countNoShow(class, id)
+	}
+	ctx.notify(messageType, q)
+
+	return q, nil
+}
+
+// countNoShow records that a student of a class was not there when a TA/teacher came to help them.
+func (ctx *Context) countNoShow(class, id string) {
+	if _, err := ctx.MongoStore.IncrementNoShow(class, id); err != nil {
+		log.Printf("cannot record the no-show of student %v in %v: %v", id, class, err)
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/http"
+	"net/http/httptest"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"testing"
+	"time"
+)
+
+// serveReorder sends a request to reorder the question of `id` in the queue of a class to `QuestionReorderHandler`.
+func serveReorder(ctx *Context, class, id, action, query string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/v1/queue/"+class+"/"+id+"/"+action+query, nil)
+	for k, v := range header {
+		r.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/queue/{class}/{id}/{action}", ctx.QuestionReorderHandler)
+	router.ServeHTTP(w, r)
+	return w
+}
+
+func TestQuestionReorderHandler_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	for _, action := range []string{"move", "requeue", "hold"} {
+		w := serveReorder(ctx, "343", "student", action, "?position=1&minutes=5", http.Header{})
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%v without credentials: expected %v, got %v: %v", action, http.StatusUnauthorized, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestQuestionReorderHandler(t *testing.T) {
+	ctx := newTestContext()
+	if err := ctx.SessionStore.Client.Ping().Err(); err != nil {
+		t.Skipf("redis is not available at %v: %v", redisAddr, err)
+	}
+
+	teacher := model.Teacher{ID: primitive.NewObjectID(), Role: model.RoleTA}
+	signIn := httptest.NewRecorder()
+	state := session.State{SessionStart: time.Now(), Interface: teacher}
+	if _, err := session.BeginSession(ctx.Keys, ctx.SessionStore, state, signIn); err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"Authorization": {signIn.Header().Get("Authorization")}}
+
+	// students of their own, so that the test does not touch anyone else in line
+	class := "343"
+	student := func(name string) string { return fmt.Sprintf("test-reorder-%v-%v", name, time.Now().UnixNano()) }
+	line := map[string]*model.Question{
+		"in progress":      {ID: student("in-progress"), Status: model.StatusInProgress, ClaimedBy: teacher.ID.Hex()},
+		"claimed by other": {ID: student("claimed"), Status: model.StatusClaimed, ClaimedBy: "someone else"},
+	}
+	for _, q := range line {
+		q.Class = class
+		q.CreatedAt = time.Now()
+		if _, err := ctx.SessionStore.Enqueue(q); err != nil {
+			t.Fatal(err)
+		}
+		id := q.ID
+		t.Cleanup(func() {
+			ctx.SessionStore.UpdateQuestion(class, id, func(q *model.Question) error {
+				return q.Transition(model.StatusArchived, "", time.Now())
+			}, nil)
+		})
+	}
+
+	cases := []struct {
+		name     string
+		student  string
+		action   string
+		query    string
+		expected int
+	}{
+		{"Move without a position", "in progress", "move", "", http.StatusBadRequest},
+		{"Move to position 0", "in progress", "move", "?position=0", http.StatusBadRequest},
+		{"Hold without minutes", "in progress", "hold", "", http.StatusBadRequest},
+		{"Unknown action", "in progress", "skip", "", http.StatusNotFound},
+		{"Move a student not in line", "", "move", "?position=1", http.StatusNotFound},
+		{"Requeue a student not in line", "", "requeue", "", http.StatusNotFound},
+		{"Hold a student not in line", "", "hold", "?minutes=5", http.StatusNotFound},
+		{"Move a student being helped", "in progress", "move", "?position=1", http.StatusConflict},
+		{"Requeue a student being helped", "in progress", "requeue", "", http.StatusConflict},
+		{"Hold a student being helped", "in progress", "hold", "?minutes=5", http.StatusConflict},
+		{"Move a student claimed by another TA", "claimed by other", "move", "?position=1", http.StatusConflict},
+		{"Requeue a student claimed by another TA", "claimed by other", "requeue", "", http.StatusConflict},
+		{"Hold a student claimed by another TA", "claimed by other", "hold", "?minutes=5", http.StatusConflict},
+	}
+
+	for _, c := range cases {
+		id := student("nobody")
+		if q, ok := line[c.student]; ok {
+			id = q.ID
+		}
+		if w := serveReorder(ctx, class, id, c.action, c.query, header); w.Code != c.expected {
+			t.Errorf("%v: expected %v, got %v: %v", c.name, c.expected, w.Code, w.Body.String())
+		}
+	}
+
+	// nothing changed the students who could not be reordered
+	queue, err := ctx.SessionStore.GetQueue(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, q := range line {
+		found := false
+		for _, helping := range queue.Helping {
+			if helping.ID == q.ID {
+				found = helping.Status == q.Status && helping.NoShows == 0 && helping.HeldUntil.IsZero()
+			}
+		}
+		if !found {
+			t.Errorf("%v: expected the student to still be helped unchanged", name)
+		}
+	}
+}
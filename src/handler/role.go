@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"strings"
+	"time"
+)
+
+var (
+	ErrForbidden       = errors.New("your role does not allow this")
+	ErrTeacherNotFound = errors.New("no such TA/teacher")
+	ErrInviteNotFound  = errors.New("no such pending invite")
+)
+
+// RequireRole is a middleware only letting through TA/teachers whose account has the role `least` or a more trusted one.
+func (ctx *Context) RequireRole(least string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		teacher, err := ctx.getTeacher(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !model.HasRole(teacher.AccountRole(), least) {
+			http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// RequireClassRole is a middleware only letting through TA/teachers with the role `read` or a more trusted one
+// in the class of the route to GET, and `write` to do anything else; an empty role lets anyone through.
+// See `model.ClassRole`.
+func (ctx *Context) RequireClassRole(read, write string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		least := write
+		if r.Method == http.MethodGet {
+			least = read
+		}
+		if len(least) == 0 {
+			next(w, r)
+			return
+		}
+
+		class := mux.Vars(r)["class"]
+		if !model.ValidateClass(class) {
+			http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, ok := ctx.authorizeClass(w, r, class, least); !ok {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// authorizeClass returns the TA/teacher of the session of the request if they have the role `least`
+// or a more trusted one in a class; otherwise it answers the request and reports false.
+func (ctx *Context) authorizeClass(w http.ResponseWriter, r *http.Request, class, least string) (*model.Teacher, bool) {
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	role, err := ctx.classRole(teacher, class)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !model.HasRole(role, least) {
+		http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+		return nil, false
+	}
+	return teacher, true
+}
+
+// classRole returns the role of a TA/teacher in a class, "" if they are not on its staff.
+func (ctx *Context) classRole(teacher *model.Teacher, class string) (string, error) {
+	if teacher.AccountRole() == model.RoleAdmin {
+		return model.RoleAdmin, nil
+	}
+
+	staff, err := ctx.MongoStore.GetStaff(class, teacher.ID.Hex())
+	if err == mongo.ErrNoDocuments {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return model.ClassRole(teacher, staff), nil
+}
+
+// TeacherRoleHandler lets an admin change the role of the account of a TA/teacher;
+// the TA/teacher is signed out everywhere so their sessions pick up the new role.
+func (ctx *Context) TeacherRoleHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPut {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+		http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	ru, err := decodeRoleUpdate(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if !model.ValidateRole(ru.Role) {
+		http.Error(w, model.ErrInvalidRole.Error(), http.StatusBadRequest)
+		return
+	}
+
+	teacher, err := ctx.getTeacherByID(mux.Vars(r)["id"])
+	if err == ErrTeacherNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := ctx.MongoStore.SetTeacherRole(teacher.ID, ru.Role); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	teacher.Role = ru.Role
+
+	if _, err := ctx.SessionStore.RevokeSessions(teacher.ID.Hex(), session.InvalidSessionID); err != nil {
+		log.Printf("cannot sign out %v after changing their role: %v", teacher.ID.Hex(), err)
+	}
+
+	b, _ := json.Marshal(teacher)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// StaffHandler lists the staff of a class, and lets its instructors add TA/teachers to it, or change their role.
+func (ctx *Context) StaffHandler(w http.ResponseWriter, r *http.Request) {
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the staff of the class
+	case http.MethodGet:
+
+		staff, err := ctx.MongoStore.GetClassStaff(class)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(staff)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// add a TA/teacher to the staff as a TA or an instructor
+	case http.MethodPost:
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		ns, err := decodeStaff(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		adder, err := ctx.getTeacher(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		teacher, err := ctx.getTeacherByID(ns.TeacherID)
+		if err == ErrTeacherNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := ns.Validate(teacher); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		staff := &model.Staff{
+			Class:     class,
+			TeacherID: teacher.ID.Hex(),
+			Role:      ns.Role,
+			AddedBy:   adder.ID.Hex(),
+			AddedAt:   time.Now(),
+		}
+		if _, err := ctx.MongoStore.SetStaff(staff); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(staff)
+		httpWriter(http.StatusCreated, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// SpecificStaffHandler lets the instructors of a class remove a TA/teacher from its staff.
+func (ctx *Context) SpecificStaffHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if !model.ValidateClass(vars["class"]) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := ctx.MongoStore.RemoveStaff(vars["class"], vars["teacher_id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if res.DeletedCount == 0 {
+		http.Error(w, "the TA/teacher is not on the staff of the class", http.StatusNotFound)
+		return
+	}
+
+	httpWriter(http.StatusOK, []byte("removed from the staff"), MimePlain, w)
+}
+
+// EnsureAdmins gives the accounts of TA/teachers with the emails given the admin role, so there is
+// always someone to give the others their roles; see `ensureAdmin`. An email without an account gets it
+// once it registers and verifies the email.
+func (ctx *Context) EnsureAdmins() {
+	for _, email := range ctx.AdminEmails {
+		teachers, err := ctx.MongoStore.GetTeacherByEmail(email)
+		if err != nil {
+			log.Printf("cannot find admin %v: %v", email, err)
+			continue
+		}
+		for _, t := range teachers {
+			ctx.ensureAdmin(t)
+		}
+	}
+}
+
+// ensureAdmin gives the account of a TA/teacher whose email is in `Context.AdminEmails` the admin role once
+// they verified the email, so that registering with someone else's email gives nothing, and takes it from them
+// until they do; they are signed out when their role changes, as with `RoleHandler`.
+func (ctx *Context) ensureAdmin(t *model.Teacher) {
+	if !ctx.isAdminEmail(t.Email) {
+		return
+	}
+
+	role := t.Role
+	if t.EmailVerified {
+		role = model.RoleAdmin
+	} else if t.Role == model.RoleAdmin {
+		role = model.RoleTA
+	}
+	if role == t.Role {
+		return
+	}
+
+	if _, err := ctx.MongoStore.SetTeacherRole(t.ID, role); err != nil {
+		log.Printf("cannot give %v the %v role: %v", t.Email, role, err)
+		return
+	}
+	t.Role = role
+
+	if _, err := ctx.SessionStore.RevokeSessions(t.ID.Hex(), session.InvalidSessionID); err != nil {
+		log.Printf("cannot sign out %v after changing their role: %v", t.ID.Hex(), err)
+	}
+}
+
+// isAdminEmail reports whether the TA/teacher with an email is an admin by `Context.AdminEmails`.
+func (ctx *Context) isAdminEmail(email string) bool {
+	for _, e := range ctx.AdminEmails {
+		if strings.EqualFold(e, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// getTeacherByID returns the TA/teacher with a hex ID without their password hash, ErrTeacherNotFound if there is none.
+func (ctx *Context) getTeacherByID(id string) (*model.Teacher, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrTeacherNotFound
+	}
+
+	teacher, err := ctx.MongoStore.GetTeacherByID(oid)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrTeacherNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	teacher.PasswordHash = ""
+	return teacher, nil
+}
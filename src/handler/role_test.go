@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"net/http/httptest"
+	"questionqueue/src/model"
+	"testing"
+)
+
+func TestRequireClassRole(t *testing.T) {
+	ctx := newTestContext()
+
+	reached := false
+	handler := ctx.RequireClassRole("", model.RoleInstructor, func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	})
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/schedule/{class}", handler)
+
+	cases := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+		reached  bool
+	}{
+		{"Public read", http.MethodGet, "/v1/schedule/343", http.StatusOK, true},
+		{"Write without session", http.MethodPut, "/v1/schedule/343", http.StatusUnauthorized, false},
+		{"Invalid class", http.MethodPut, "/v1/schedule/999", http.StatusBadRequest, false},
+	}
+
+	for _, c := range cases {
+		reached = false
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code != c.expected || reached != c.reached {
+			t.Errorf("%v: expected %v and reached to be %v, got %v and %v", c.name, c.expected, c.reached, w.Code, reached)
+		}
+	}
+}
+
+func TestRequireRole_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	handler := ctx.RequireRole(model.RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected the request not to get through")
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/admin/reconcile", nil)
+	r.Header.Set("Authorization", "Bearer not-a-session")
+	w := httptest.NewRecorder()
+	handler(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %v, got %v", http.StatusUnauthorized, w.Code)
+	}
+}
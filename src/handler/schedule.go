@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+	"log"
+	"net/http"
+	"questionqueue/src/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduler is who the queue state is updated by when a schedule opens or closes a queue.
+const scheduler = "schedule"
+
+// ScheduleHandler returns the weekly schedule of a class to anyone and lets a TA/teacher replace it.
+func (ctx *Context) ScheduleHandler(w http.ResponseWriter, r *http.Request) {
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	// get the schedule of the class
+	case http.MethodGet:
+
+		schedule, err := ctx.MongoStore.GetSchedule(class)
+		if err == mongo.ErrNoDocuments {
+			http.Error(w, "the class has no schedule", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(schedule)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// replace the schedule of the class
+	case http.MethodPut:
+
+		if _, err := ctx.getTeacher(r); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), MimeJson) {
+			http.Error(w, ErrUnsupportedMediaType.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		schedule, err := decodeSchedule(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		schedule.Class = class
+		if err := schedule.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := ctx.MongoStore.SetSchedule(schedule); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(schedule)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// NextMeetingsHandler returns the upcoming labs and office hours of a class to anyone, soonest first;
+// query parameter `n` limits how many, 1 by default. A meeting in progress comes first.
+func (ctx *Context) NextMeetingsHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	class := mux.Vars(r)["class"]
+	if !model.ValidateClass(class) {
+		http.Error(w, ErrInvalidClass.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n := 1
+	if param := r.URL.Query().Get("n"); len(param) != 0 {
+		var err error
+		if n, err = strconv.Atoi(param); err != nil || n < 1 {
+			http.Error(w, "n must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	schedule, err := ctx.MongoStore.GetSchedule(class)
+	if err == mongo.ErrNoDocuments {
+		http.Error(w, "the class has no schedule", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	meetings := schedule.Meetings(time.Now(), n)
+	if meetings == nil {
+		meetings = []*model.Meeting{}
+	}
+
+	b, _ := json.Marshal(meetings)
+	httpWriter(http.StatusOK, b, MimeJson, w)
+}
+
+// RunSchedules opens the queue of every class with a schedule at the start of each of its meetings,
+// and closes it at the end, archiving the questions left; it checks every `interval` and never returns.
+// Only the start and end of a meeting change the queue, so TA/teachers can still pause or close it in between.
+// It first catches up with the starts and ends missed while the service was down, see `catchUpSchedules`.
+func (ctx *Context) RunSchedules(interval time.Duration) {
+	last := time.Now()
+	ctx.catchUpSchedules(last)
+	for now := range time.Tick(interval) {
+		ctx.applySchedules(last, now)
+		last = now
+	}
+}
+
+// applySchedules opens and closes the queues whose meetings started or ended after `from` up to `to`.
+func (ctx *Context) applySchedules(from, to time.Time) {
+
+	schedules, err := ctx.MongoStore.GetAllSchedules()
+	if err != nil {
+		log.Printf("cannot read the schedules: %v", err)
+		return
+	}
+
+	within := func(t time.Time) bool { return t.After(from) && !t.After(to) }
+
+	for _, schedule := range schedules {
+		// every meeting that ends after `from` comes within a week
+		for _, m := range schedule.Meetings(from, len(schedule.Slots)*2) {
+			if within(m.Start) {
+				if err := ctx.openQueue(m); err != nil {
+					log.Printf("cannot open the queue of %v: %v", m.Class, err)
+				}
+			}
+			if within(m.End) && !stillMeeting(schedule, m) {
+				if err := ctx.closeQueue(m); err != nil {
+					log.Printf("cannot close the queue of %v: %v", m.Class, err)
+				}
+			}
+		}
+	}
+}
+
+// catchUpSchedules brings the queue of every class with a schedule to the state the last start or end of
+// one of its meetings up to `at` called for, unless the queue changed since; a start or end that came while
+// the service was down is applied late, and the ones it saw, or a TA/teacher overrode, are left alone.
+func (ctx *Context) catchUpSchedules(at time.Time) {
+
+	schedules, err := ctx.MongoStore.GetAllSchedules()
+	if err != nil {
+		log.Printf("cannot read the schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		m, opened := schedule.LastChange(at)
+		if m == nil {
+			continue
+		}
+
+		state, err := ctx.SessionStore.GetQueueState(schedule.Class)
+		if err != nil {
+			log.Printf("cannot read the state of the queue of %v: %v", schedule.Class, err)
+			continue
+		}
+
+		switch {
+		case opened && state.UpdatedAt.Before(m.Start):
+			if err := ctx.openQueue(m); err != nil {
+				log.Printf("cannot open the queue of %v: %v", m.Class, err)
+			}
+		case !opened && state.UpdatedAt.Before(m.End) && !stillMeeting(schedule, m):
+			if err := ctx.closeQueue(m); err != nil {
+				log.Printf("cannot close the queue of %v: %v", m.Class, err)
+			}
+		}
+	}
+}
+
+// stillMeeting reports whether another meeting of a schedule that started before `m` ended is still in progress
+// at its end, so that its queue stays open; only a schedule saved before overlapping slots were rejected has one.
+func stillMeeting(schedule *model.Schedule, m *model.Meeting) bool {
+	for _, other := range schedule.InProgress(m.End) {
+		if other.Start.Before(m.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// openQueue opens the queue of the class of a meeting, keeping its length limit,
+// along with a lab session in the location of the meeting.
+func (ctx *Context) openQueue(m *model.Meeting) error {
+
+	if _, err := openLabSession(ctx, m.Class, m.Location, nil); err != nil && err != model.ErrLabSessionOpen {
+		log.Printf("cannot open a lab session of %v: %v", m.Class, err)
+	}
+
+	state, err := ctx.SessionStore.GetQueueState(m.Class)
+	if err != nil {
+		return err
+	}
+
+	state.Status = model.QueueOpen
+	state.Message = ""
+	state.LastCall = time.Time{}
+	state.UpdatedBy = scheduler
+	state.UpdatedAt = time.Now()
+	return setQueueState(ctx, state)
+}
+
+// closeQueue closes the queue of the class of a meeting and archives the questions left in it,
+// then closes its lab session.
+func (ctx *Context) closeQueue(m *model.Meeting) error {
+
+	state, err := ctx.SessionStore.GetQueueState(m.Class)
+	if err != nil {
+		return err
+	}
+
+	state.Status = model.QueueClosed
+	state.Message = ""
+	state.UpdatedBy = scheduler
+	state.UpdatedAt = time.Now()
+	if err := setQueueState(ctx, state); err != nil {
+		return err
+	}
+
+	queue, err := ctx.SessionStore.GetQueue(m.Class)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range append(queue.Helping, queue.Queue...) {
+		if _, err := transitionQuestion(ctx, m.Class, q.ID, model.StatusArchived, ""); err != nil && err != ErrQuestionNotFound {
+			log.Printf("cannot archive question %v: %v", q.QuestionID.Hex(), err)
+		}
+	}
+
+	if _, err := closeLabSession(ctx, m.Class); err != nil && err != model.ErrLabSessionNotFound {
+		log.Printf("cannot close the lab session of %v: %v", m.Class, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"net"
+	"net/http"
+	"questionqueue/src/model"
+	"questionqueue/src/session"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TeacherSessionsHandler lists the active sessions of a TA/teacher, with when and from which device
+// they signed in, and signs them out everywhere.
+func (ctx *Context) TeacherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+
+	teacher := &model.Teacher{}
+	sid, err := session.GetState(r, ctx.Keys, ctx.SessionStore, &session.State{Interface: teacher})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	// get the active sessions
+	case http.MethodGet:
+
+		sessions, err := ctx.SessionStore.GetSessions(teacher.ID.Hex(), sid)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := json.Marshal(sessions)
+		httpWriter(http.StatusOK, b, MimeJson, w)
+
+	// sign out every session, or every other one with `others=true`
+	case http.MethodDelete:
+
+		keep := session.SessionID(session.InvalidSessionID)
+		if r.URL.Query().Get("others") == "true" {
+			keep = sid
+		}
+
+		revoked, err := ctx.SessionStore.RevokeSessions(teacher.ID.Hex(), keep)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		httpWriter(http.StatusOK, []byte(strconv.Itoa(revoked)+" sessions signed out"), MimePlain, w)
+
+	default:
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// SpecificTeacherSessionHandler signs out one session of a TA/teacher, given by the `id` it is listed with.
+func (ctx *Context) SpecificTeacherSessionHandler(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, ErrMethodNotAllowed.Error(), http.StatusMethodNotAllowed)
+		return
+	}
+
+	teacher, err := ctx.getTeacher(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = ctx.SessionStore.RevokeSession(teacher.ID.Hex(), mux.Vars(r)["session_id"])
+	if err == session.ErrStateNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	httpWriter(http.StatusOK, []byte("session signed out"), MimePlain, w)
+}
+
+// beginSession signs a TA/teacher in from the device of the request and adds the session to their sessions.
+func (ctx *Context) beginSession(w http.ResponseWriter, r *http.Request, t model.Teacher) error {
+	state := session.State{
+		SessionStart: time.Now(),
+		Interface:    t,
+		UserAgent:    r.UserAgent(),
+		IP:           clientIP(r),
+	}
+
+	sid, err := session.BeginSession(ctx.Keys, ctx.SessionStore, state, w)
+	if err != nil {
+		return err
+	}
+	return ctx.SessionStore.IndexSession(t.ID.Hex(), sid, &state)
+}
+
+// clientIP returns the IP address of the client of a request as the gateway saw it,
+// the last address forwarded; the ones before come from the client and could be anything.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); len(forwarded) != 0 {
+		addrs := strings.Split(forwarded, ",")
+		return strings.TrimSpace(addrs[len(addrs)-1])
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTeacherSessionsHandler_Unauthorized(t *testing.T) {
+	ctx := newTestContext()
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		w := httptest.NewRecorder()
+		ctx.TeacherSessionsHandler(w, httptest.NewRequest(method, "/v1/teacher/sessions", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%v: expected %v, got %v: %v", method, http.StatusUnauthorized, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	ctx.SpecificTeacherSessionHandler(w, httptest.NewRequest(http.MethodDelete, "/v1/teacher/sessions/abc", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected %v, got %v: %v", http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	cases := []struct {
+		forwarded string
+		remote    string
+		expected  string
+	}{
+		{"", "10.0.0.1:1234", "10.0.0.1"},
+		{"203.0.113.7", "10.0.0.1:1234", "203.0.113.7"},
+		{"1.2.3.4, 203.0.113.7", "10.0.0.1:1234", "203.0.113.7"},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = c.remote
+		if len(c.forwarded) != 0 {
+			r.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if ip := clientIP(r); ip != c.expected {
+			t.Errorf("%q from %v: expected %v, got %v", c.forwarded, c.remote, c.expected, ip)
+		}
+	}
+}
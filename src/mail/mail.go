@@ -0,0 +1,121 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is a plain text email to one recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends emails; see `SMTPSender`, and `FileSender` and `MemorySender` for local development and tests.
+type Sender interface {
+	Send(m *Message) error
+}
+
+// SMTPSender sends emails from `From` through the SMTP server at `Addr`, as `host:port`.
+type SMTPSender struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+// NewSMTPSender returns a sender through the SMTP server at `addr`,
+// signing in with PLAIN auth if a username is given.
+func NewSMTPSender(addr, from, username, password string) *SMTPSender {
+	s := &SMTPSender{Addr: addr, From: from}
+	if len(username) != 0 {
+		host, _, _ := net.SplitHostPort(addr)
+		s.Auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+// Send sends an email through the SMTP server.
+func (s *SMTPSender) Send(m *Message) error {
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{m.To}, m.Bytes(s.From, time.Now()))
+}
+
+// FileSender appends emails to the file at `Path` instead of sending them, for local development.
+type FileSender struct {
+	Path string
+	From string
+	mx   sync.Mutex
+}
+
+// NewFileSender returns a sender appending emails to the file at `path`.
+func NewFileSender(path, from string) *FileSender {
+	return &FileSender{Path: path, From: from}
+}
+
+// Send appends an email to the file.
+func (s *FileSender) Send(m *Message) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(m.Bytes(s.From, time.Now()), '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// MemorySender keeps the emails it is given instead of sending them, for tests.
+type MemorySender struct {
+	mx   sync.Mutex
+	sent []*Message
+}
+
+// NewMemorySender returns a sender keeping the emails in memory.
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send keeps an email.
+func (s *MemorySender) Send(m *Message) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.sent = append(s.sent, m)
+	return nil
+}
+
+// Sent returns the emails sent so far, earliest first.
+func (s *MemorySender) Sent() []*Message {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return append([]*Message(nil), s.sent...)
+}
+
+// Bytes returns the email as sent from `from` at a given time, headers and body, with CRLF line endings.
+func (m *Message) Bytes(from string, at time.Time) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", from)
+	fmt.Fprintf(&b, "To: %v\r\n", stripNewlines(m.To))
+	fmt.Fprintf(&b, "Subject: %v\r\n", stripNewlines(m.Subject))
+	fmt.Fprintf(&b, "Date: %v\r\n", at.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.Replace(strings.Replace(m.Body, "\r\n", "\n", -1), "\n", "\r\n", -1))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+// stripNewlines removes the line breaks of a header value, so it cannot add headers of its own.
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
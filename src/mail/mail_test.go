@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessage_Bytes(t *testing.T) {
+	m := &Message{To: "ta@uw.edu", Subject: "Hi\r\nBcc: someone@uw.edu", Body: "line one\nline two"}
+	b := string(m.Bytes("queue@uw.edu", time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)))
+
+	if !strings.Contains(b, "Subject: HiBcc: someone@uw.edu\r\n") {
+		t.Errorf("expected the subject not to add headers, got %q", b)
+	}
+	if !strings.HasSuffix(b, "\r\n\r\nline one\r\nline two\r\n") {
+		t.Errorf("expected the body after the headers with CRLF line endings, got %q", b)
+	}
+}
+
+func TestMemorySender(t *testing.T) {
+	var s Sender = NewMemorySender()
+	for _, to := range []string{"a@uw.edu", "b@uw.edu"} {
+		if err := s.Send(&Message{To: to}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	sent := s.(*MemorySender).Sent()
+	if len(sent) != 2 || sent[0].To != "a@uw.edu" || sent[1].To != "b@uw.edu" {
+		t.Errorf("expected both emails in order, got %v", sent)
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mail.log")
+
+	s := NewFileSender(path, "queue@uw.edu")
+	for _, subject := range []string{"first", "second"} {
+		if err := s.Send(&Message{To: "ta@uw.edu", Subject: subject}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(string(b), "Subject: first") || !strings.Contains(string(b), "Subject: second") {
+		t.Errorf("expected both emails in the file, got %q", b)
+	}
+}
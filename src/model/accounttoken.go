@@ -0,0 +1,155 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// What account tokens are for; a token for one cannot be used for the other.
+const (
+	// lets a TA/teacher who forgot their password set a new one
+	PurposeResetPassword = "reset-password"
+	// proves a TA/teacher gets the mail sent to their email
+	PurposeVerifyEmail = "verify-email"
+)
+
+// How long account tokens can be used once made.
+const (
+	ResetPasswordLifetime = time.Hour
+	VerifyEmailLifetime   = 48 * time.Hour
+)
+
+var ErrInvalidAccountToken = errors.New("invalid or expired token")
+
+// AccountToken is what an account token says, signed by the server: that the TA/teacher of `TeacherID`
+// can do `Purpose` until `ExpiresAt`. `Stamp` is a hash of what the token acts on, their password hash
+// or their email, so a token is spent once the password changes and cannot verify another email.
+type AccountToken struct {
+	Purpose   string    `json:"purpose"`
+	TeacherID string    `json:"teacher_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Stamp     string    `json:"stamp"`
+}
+
+// NewAccountToken returns a token for a TA/teacher to do `purpose` with, signed with the key derived
+// from `key` for the purpose, see `purposeKey`.
+func NewAccountToken(purpose string, t *Teacher, key string, at time.Time) (string, error) {
+	lifetime := ResetPasswordLifetime
+	if purpose == PurposeVerifyEmail {
+		lifetime = VerifyEmailLifetime
+	} else if purpose != PurposeResetPassword {
+		return "", ErrInvalidAccountToken
+	}
+
+	b, err := json.Marshal(&AccountToken{
+		Purpose:   purpose,
+		TeacherID: t.ID.Hex(),
+		ExpiresAt: at.Add(lifetime),
+		Stamp:     t.tokenStamp(purpose),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + signAccountToken(payload, purposeKey(key, purpose)), nil
+}
+
+// ParseAccountToken returns what a token for `purpose` says if it was signed with the key derived for
+// the purpose from one of `keys` and has not expired at a given time; see `AccountToken.Matches` for whether it is still of use.
+func ParseAccountToken(token, purpose string, keys []string, at time.Time) (*AccountToken, error) {
+	i := strings.LastIndex(token, ".")
+	if i <= 0 {
+		return nil, ErrInvalidAccountToken
+	}
+	payload, sig := token[:i], token[i+1:]
+
+	signed := false
+	for _, key := range keys {
+		if hmac.Equal([]byte(sig), []byte(signAccountToken(payload, purposeKey(key, purpose)))) {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return nil, ErrInvalidAccountToken
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidAccountToken
+	}
+	tok := &AccountToken{}
+	if err := json.Unmarshal(b, tok); err != nil {
+		return nil, ErrInvalidAccountToken
+	}
+	if tok.Purpose != purpose || !at.Before(tok.ExpiresAt) {
+		return nil, ErrInvalidAccountToken
+	}
+	return tok, nil
+}
+
+// Matches reports whether the token was made for a TA/teacher as they are now.
+func (tok *AccountToken) Matches(t *Teacher) bool {
+	return tok.TeacherID == t.ID.Hex() && hmac.Equal([]byte(tok.Stamp), []byte(t.tokenStamp(tok.Purpose)))
+}
+
+// tokenStamp returns the hash of what a token for `purpose` acts on, see `AccountToken`.
+func (t *Teacher) tokenStamp(purpose string) string {
+	of := t.PasswordHash
+	if purpose == PurposeVerifyEmail {
+		of = strings.ToLower(t.Email)
+	}
+	sum := sha256.Sum256([]byte(purpose + ":" + of))
+	return hex.EncodeToString(sum[:16])
+}
+
+// purposeKey derives the key account tokens for `purpose` are signed with from a signing key,
+// so that neither a token for another purpose nor anything else signed with the key, like a session ID,
+// passes for one.
+func purposeKey(key, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte("account-token:" + purpose))
+	return string(mac.Sum(nil))
+}
+
+// signAccountToken returns the signature of the payload of a token with a key.
+func signAccountToken(payload, key string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// PasswordResetRequest is the email of a TA/teacher who forgot their password.
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// PasswordReset is a new password a TA/teacher sets with a reset token.
+type PasswordReset struct {
+	Token        string `json:"token"`
+	Password     string `json:"password"`
+	PasswordConf string `json:"password_conf"`
+}
+
+// EmailVerification is a verify-email token a TA/teacher was sent.
+type EmailVerification struct {
+	Token string `json:"token"`
+}
+
+// Validate checks the new password of a password reset, as `NewTeacher.VerifyNewTeacher` does.
+func (pr *PasswordReset) Validate() error {
+	if pr.Password != pr.PasswordConf {
+		return errors.New("passwords do not match")
+	}
+	if len(pr.Password) < 6 {
+		return errors.New("password needs to be more than 6 characters long")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccountToken(t *testing.T) {
+	at := time.Now()
+	teacher := &Teacher{ID: primitive.NewObjectID(), Email: "ta@uw.edu", PasswordHash: "hash"}
+
+	token, err := NewAccountToken(PurposeResetPassword, teacher, "key", at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tok, err := ParseAccountToken(token, PurposeResetPassword, []string{"new key", "key"}, at)
+	if err != nil {
+		t.Fatalf("expected a token signed with an accepted key to parse, got %v", err)
+	}
+	if !tok.Matches(teacher) {
+		t.Errorf("expected the token to match the TA/teacher it was made for")
+	}
+
+	changed := *teacher
+	changed.PasswordHash = "new hash"
+	if tok.Matches(&changed) {
+		t.Errorf("expected a reset token to be spent once the password changed")
+	}
+
+	// the same payload signed with the key itself, or with the key of another purpose
+	payload := token[:strings.LastIndex(token, ".")]
+	plain := payload + "." + signAccountToken(payload, "key")
+	crossed := payload + "." + signAccountToken(payload, purposeKey("key", PurposeVerifyEmail))
+
+	cases := []struct {
+		name    string
+		token   string
+		purpose string
+		keys    []string
+		at      time.Time
+	}{
+		{"Other purpose", token, PurposeVerifyEmail, []string{"key"}, at},
+		{"Other key", token, PurposeResetPassword, []string{"new key"}, at},
+		{"Expired", token, PurposeResetPassword, []string{"key"}, at.Add(ResetPasswordLifetime)},
+		{"Tampered", "x" + token, PurposeResetPassword, []string{"key"}, at},
+		{"Unsigned", "token", PurposeResetPassword, []string{"key"}, at},
+		{"Signed with the plain key", plain, PurposeResetPassword, []string{"key"}, at},
+		{"Signed for another purpose", crossed, PurposeResetPassword, []string{"key"}, at},
+	}
+
+	for _, c := range cases {
+		if _, err := ParseAccountToken(c.token, c.purpose, c.keys, c.at); err != ErrInvalidAccountToken {
+			t.Errorf("%v: expected %v, got %v", c.name, ErrInvalidAccountToken, err)
+		}
+	}
+}
+
+func TestAccountToken_VerifyEmail(t *testing.T) {
+	at := time.Now()
+	teacher := &Teacher{ID: primitive.NewObjectID(), Email: "ta@uw.edu"}
+
+	token, err := NewAccountToken(PurposeVerifyEmail, teacher, "key", at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tok, err := ParseAccountToken(token, PurposeVerifyEmail, []string{"key"}, at.Add(ResetPasswordLifetime))
+	if err != nil {
+		t.Fatalf("expected a verify-email token to last longer than a reset token, got %v", err)
+	}
+
+	other := *teacher
+	other.Email = "someone@uw.edu"
+	if !tok.Matches(teacher) || tok.Matches(&other) {
+		t.Errorf("expected the token to only verify the email it was sent to")
+	}
+}
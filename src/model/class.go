@@ -1,8 +1,9 @@
 package model
 
+// Class is a class as the class service stores it; `Type` lists the topics of its questions.
 type Class struct {
-	Code string   `bson:"code"`
-	Type []string `bson:"type"`
+	Code string   `bson:"class_number"`
+	Type []string `bson:"topics"`
 }
 
 func ValidateClass (code string) bool {
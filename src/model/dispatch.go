@@ -0,0 +1,242 @@
+package model
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"math"
+	"time"
+)
+
+// DefaultFallbackMinutes is how long a student waits before they are next for any TA/teacher,
+// whatever the topics they cover, when a class never had its dispatch set.
+const DefaultFallbackMinutes = 15
+
+// MaxDispatchMinutes bounds the minutes of a dispatch to a week.
+const MaxDispatchMinutes = 7 * 24 * 60
+
+// Dispatch modes; the topics a TA/teacher covers count in both.
+const (
+	// the first student in line is next
+	DispatchInLine = "in-line"
+	// the student nearest to the TA/teacher is next, within `MaxSkips` and `MaxDelayMinutes`
+	DispatchProximity = "proximity"
+)
+
+var (
+	ErrUnknownTopic    = errors.New("the class has no such topic")
+	ErrInvalidDispatch = errors.New("invalid dispatch")
+	ErrOverdueAhead    = errors.New("a student ahead in line has to be helped first")
+)
+
+// Point is a location in the lab, in the coordinates of `Question.Loc_X` and `Question.Loc_Y`.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Duty is a TA/teacher on duty in the queue of a class and the topics of the class they cover.
+type Duty struct {
+	TeacherID string    `json:"teacher_id"`
+	Topics    []string  `json:"topics"`
+	Since     time.Time `json:"since"`
+	// where the TA/teacher is in the lab, if known
+	Location *Point `json:"location,omitempty"`
+}
+
+// Dispatch is how the students waiting in line of a class are handed out to the TA/teachers asking for their next one.
+type Dispatch struct {
+	Class string `json:"class"`
+	Mode  string `json:"mode"`
+	// minutes after which a student goes to the next TA/teacher, whether they cover the topic or not;
+	// 0 means `DefaultFallbackMinutes`
+	FallbackMinutes int `json:"fallback_minutes"`
+	// a student passed over this many times is next; 0 means no limit
+	MaxSkips int `json:"max_skips"`
+	// a student is next once this many minutes went by since they were first passed over; 0 means no limit
+	MaxDelayMinutes int `json:"max_delay_minutes"`
+}
+
+// Skip is a record of a student passed over by a TA/teacher helping someone behind them in line.
+type Skip struct {
+	Class      string             `json:"class"`
+	StudentID  string             `json:"student_id"`
+	QuestionID primitive.ObjectID `json:"question_id"`
+	TeacherID  string             `json:"teacher_id"`
+	// the student helped instead
+	HelpedID string `json:"helped_id"`
+	Mode     string `json:"mode"`
+	// times the student was passed over, this one included
+	Skips int       `json:"skips"`
+	At    time.Time `json:"at"`
+}
+
+// NewDispatch returns the dispatch of a class that never had one set.
+func NewDispatch(class string) *Dispatch {
+	return &Dispatch{Class: class, Mode: DispatchInLine, FallbackMinutes: DefaultFallbackMinutes}
+}
+
+// Validate checks the fields of a dispatch a TA/teacher can set, and fills in the mode and fallback left out;
+// proximity dispatch must bound how often and how long a student can be passed over.
+func (d *Dispatch) Validate() error {
+	if d.FallbackMinutes < 0 || d.MaxSkips < 0 || d.MaxDelayMinutes < 0 {
+		return ErrInvalidDispatch
+	}
+	if d.FallbackMinutes > MaxDispatchMinutes || d.MaxDelayMinutes > MaxDispatchMinutes {
+		return ErrInvalidDispatch
+	}
+	if d.FallbackMinutes == 0 {
+		d.FallbackMinutes = DefaultFallbackMinutes
+	}
+	switch d.Mode {
+	case "":
+		d.Mode = DispatchInLine
+	case DispatchInLine:
+	case DispatchProximity:
+		if d.MaxSkips == 0 || d.MaxDelayMinutes == 0 {
+			return ErrInvalidDispatch
+		}
+	default:
+		return ErrInvalidDispatch
+	}
+	return nil
+}
+
+// ValidateTopics checks that a TA/teacher only covers topics of the class.
+func (d *Duty) ValidateTopics(class *Class) error {
+	for _, topic := range d.Topics {
+		found := false
+		for _, t := range class.Type {
+			if t == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrUnknownTopic
+		}
+	}
+	return nil
+}
+
+// Covers reports whether the TA/teacher helps with questions of a topic;
+// a TA/teacher who declared no topics covers them all.
+func (d *Duty) Covers(topic string) bool {
+	if d == nil || len(d.Topics) == 0 {
+		return true
+	}
+	for _, t := range d.Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the student waiting in line a TA/teacher on `duty` should help next at a given time, or nil if nobody is waiting,
+// along with the students ahead of them in line the TA/teacher passes over. Students on hold are skipped.
+// The first student in line who waited longer than `FallbackMinutes`, was passed over `MaxSkips` times or
+// `MaxDelayMinutes` ago is next; otherwise, among the students whose topic the TA/teacher covers, or all of them
+// if they cover none, the first in line, or in proximity mode the nearest to the TA/teacher.
+func (d *Dispatch) Next(queue *QuestionQueue, duty *Duty, at time.Time) (*Question, []*Question) {
+	var candidates, covered []*Question
+	for _, q := range queue.Queue {
+		if q.IsHeld(at) {
+			continue
+		}
+		candidates = append(candidates, q)
+		if d.isOverdue(q, at) {
+			return q, passedOver(candidates, q)
+		}
+		if duty.Covers(q.Topic) {
+			covered = append(covered, q)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	if len(covered) == 0 {
+		covered = candidates
+	}
+
+	next := covered[0]
+	if d.Mode == DispatchProximity && duty != nil && duty.Location != nil {
+		for _, q := range covered[1:] {
+			if q.distance(duty.Location) < next.distance(duty.Location) {
+				next = q
+			}
+		}
+	}
+	return next, passedOver(candidates, next)
+}
+
+// Claim returns the students of `ids` waiting in line a TA/teacher chose to help at a given time, in the order of
+// the line, along with the students ahead of them the TA/teacher passes over; students on hold are skipped.
+// A TA/teacher cannot pass over a student who has to be next, see `Next`, and gets `ErrOverdueAhead` instead.
+func (d *Dispatch) Claim(queue *QuestionQueue, ids []string, at time.Time) ([]*Question, []*Question, error) {
+	chosen := make(map[string]bool)
+	for _, id := range ids {
+		chosen[id] = true
+	}
+
+	var claimed, ahead, skipped []*Question
+	for _, q := range queue.Queue {
+		if chosen[q.ID] {
+			claimed = append(claimed, q)
+			skipped = append(skipped, ahead...)
+			ahead = nil
+			continue
+		}
+		if !q.IsHeld(at) {
+			ahead = append(ahead, q)
+		}
+	}
+
+	for _, q := range skipped {
+		if d.isOverdue(q, at) {
+			return nil, nil, ErrOverdueAhead
+		}
+	}
+	return claimed, skipped, nil
+}
+
+// isOverdue reports whether a student has to be next at a given time, whatever the TA/teacher.
+func (d *Dispatch) isOverdue(q *Question, at time.Time) bool {
+	fallback := d.FallbackMinutes
+	if fallback <= 0 {
+		fallback = DefaultFallbackMinutes
+	}
+	if at.Sub(q.CreatedAt) > time.Duration(fallback)*time.Minute {
+		return true
+	}
+	if d.MaxSkips > 0 && q.Skips >= d.MaxSkips {
+		return true
+	}
+	return d.MaxDelayMinutes > 0 && !q.SkippedAt.IsZero() &&
+		at.Sub(q.SkippedAt) >= time.Duration(d.MaxDelayMinutes)*time.Minute
+}
+
+// Pass records that the student was passed over by a TA/teacher at a given time.
+func (q *Question) Pass(at time.Time) {
+	q.Skips++
+	if q.SkippedAt.IsZero() {
+		q.SkippedAt = at
+	}
+}
+
+// distance returns how far the student is from a point in the lab.
+func (q *Question) distance(p *Point) float64 {
+	return math.Hypot(q.Loc_X-p.X, q.Loc_Y-p.Y)
+}
+
+// passedOver returns the students ahead of `next` in line.
+func passedOver(line []*Question, next *Question) []*Question {
+	var ahead []*Question
+	for _, q := range line {
+		if q == next {
+			break
+		}
+		ahead = append(ahead, q)
+	}
+	return ahead
+}
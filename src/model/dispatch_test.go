@@ -0,0 +1,201 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDispatch_Next(t *testing.T) {
+	now := time.Now()
+
+	line := []*Question{
+		{ID: "a", Topic: "recursion", CreatedAt: now.Add(-10 * time.Minute), HeldUntil: now.Add(time.Minute)},
+		{ID: "b", Topic: "recursion", CreatedAt: now.Add(-8 * time.Minute)},
+		{ID: "c", Topic: "pointers", CreatedAt: now.Add(-6 * time.Minute)},
+	}
+
+	cases := []struct {
+		name     string
+		dispatch *Dispatch
+		duty     *Duty
+		expected string
+	}{
+		{"Not on duty", NewDispatch("class"), nil, "b"},
+		{"Covering every topic", NewDispatch("class"), &Duty{}, "b"},
+		{"Covering a topic further back", NewDispatch("class"), &Duty{Topics: []string{"pointers"}}, "c"},
+		{"Covering no topic in line", NewDispatch("class"), &Duty{Topics: []string{"loops"}}, "b"},
+		{"Waited too long", &Dispatch{FallbackMinutes: 7}, &Duty{Topics: []string{"pointers"}}, "b"},
+	}
+
+	for _, c := range cases {
+		next, _ := c.dispatch.Next(&QuestionQueue{Queue: line}, c.duty, now)
+		if next == nil || next.ID != c.expected {
+			t.Errorf("%v: expected %v next, got %v", c.name, c.expected, next)
+		}
+	}
+
+	if next, _ := NewDispatch("class").Next(&QuestionQueue{}, nil, now); next != nil {
+		t.Errorf("expected nobody next in an empty line, got %v", next.ID)
+	}
+}
+
+func TestDispatch_NextByProximity(t *testing.T) {
+	now := time.Now()
+
+	line := func() []*Question {
+		return []*Question{
+			{ID: "a", CreatedAt: now.Add(-3 * time.Minute), Loc_X: 10, Loc_Y: 10},
+			{ID: "b", CreatedAt: now.Add(-2 * time.Minute), Loc_X: 5, Loc_Y: 5},
+			{ID: "c", CreatedAt: now.Add(-1 * time.Minute), Loc_X: 1, Loc_Y: 1},
+		}
+	}
+	dispatch := &Dispatch{Mode: DispatchProximity, FallbackMinutes: 15, MaxSkips: 2, MaxDelayMinutes: 5}
+	duty := &Duty{Location: &Point{0, 0}}
+
+	cases := []struct {
+		name     string
+		update   func(line []*Question)
+		duty     *Duty
+		expected string
+		skipped  int
+	}{
+		{"Nearest", func([]*Question) {}, duty, "c", 2},
+		{"Location unknown", func([]*Question) {}, &Duty{}, "a", 0},
+		{"Passed over too often", func(l []*Question) { l[1].Skips = 2 }, duty, "b", 1},
+		{"Passed over too long ago", func(l []*Question) { l[0].SkippedAt = now.Add(-5 * time.Minute) }, duty, "a", 0},
+		{"Passed over recently", func(l []*Question) { l[0].Skips, l[0].SkippedAt = 1, now.Add(-time.Minute) }, duty, "c", 2},
+	}
+
+	for _, c := range cases {
+		l := line()
+		c.update(l)
+		next, skipped := dispatch.Next(&QuestionQueue{Queue: l}, c.duty, now)
+		if next == nil || next.ID != c.expected {
+			t.Errorf("%v: expected %v next, got %v", c.name, c.expected, next)
+		}
+		if len(skipped) != c.skipped {
+			t.Errorf("%v: expected %v passed over, got %v", c.name, c.skipped, len(skipped))
+		}
+	}
+
+	// passing a student over as often as allowed makes them next
+	l := line()
+	for i := 0; i < dispatch.MaxSkips; i++ {
+		next, skipped := dispatch.Next(&QuestionQueue{Queue: l}, duty, now)
+		if next.ID != "c" {
+			t.Fatalf("expected c next, got %v", next.ID)
+		}
+		for _, q := range skipped {
+			q.Pass(now)
+		}
+	}
+	if next, _ := dispatch.Next(&QuestionQueue{Queue: l}, duty, now); next.ID != "a" {
+		t.Errorf("expected a next after being passed over %v times, got %v", dispatch.MaxSkips, next.ID)
+	}
+	if !l[0].SkippedAt.Equal(now) {
+		t.Errorf("expected a first passed over at %v, got %v", now, l[0].SkippedAt)
+	}
+}
+
+func TestDispatch_Claim(t *testing.T) {
+	now := time.Now()
+
+	line := func() []*Question {
+		return []*Question{
+			{ID: "a", CreatedAt: now.Add(-4 * time.Minute)},
+			{ID: "b", CreatedAt: now.Add(-3 * time.Minute), HeldUntil: now.Add(time.Minute)},
+			{ID: "c", CreatedAt: now.Add(-2 * time.Minute)},
+			{ID: "d", CreatedAt: now.Add(-1 * time.Minute)},
+		}
+	}
+	dispatch := &Dispatch{Mode: DispatchProximity, FallbackMinutes: 15, MaxSkips: 2, MaxDelayMinutes: 5}
+
+	cases := []struct {
+		name     string
+		update   func(line []*Question)
+		ids      []string
+		claimed  string
+		skipped  string
+		expected error
+	}{
+		{"First in line", func([]*Question) {}, []string{"a"}, "a", "", nil},
+		{"Behind students, one on hold", func([]*Question) {}, []string{"d"}, "d", "ac", nil},
+		{"Group", func([]*Question) {}, []string{"d", "a"}, "ad", "c", nil},
+		{"Not waiting", func([]*Question) {}, []string{"nobody"}, "", "", nil},
+		{"Behind a student passed over too often", func(l []*Question) { l[0].Skips = 2 }, []string{"c"}, "", "", ErrOverdueAhead},
+		{"Behind an overdue student on hold", func(l []*Question) { l[1].Skips = 2 }, []string{"c"}, "c", "a", nil},
+		{"Behind a student who waited too long", func(l []*Question) { l[2].CreatedAt = now.Add(-time.Hour) }, []string{"d"}, "", "", ErrOverdueAhead},
+	}
+
+	ids := func(questions []*Question) string {
+		s := ""
+		for _, q := range questions {
+			s += q.ID
+		}
+		return s
+	}
+	for _, c := range cases {
+		l := line()
+		c.update(l)
+		claimed, skipped, err := dispatch.Claim(&QuestionQueue{Queue: l}, c.ids, now)
+		if err != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+		if ids(claimed) != c.claimed || ids(skipped) != c.skipped {
+			t.Errorf("%v: expected %q claimed passing over %q, got %q and %q", c.name, c.claimed, c.skipped, ids(claimed), ids(skipped))
+		}
+	}
+}
+
+func TestDispatch_Validate(t *testing.T) {
+	cases := []struct {
+		dispatch *Dispatch
+		valid    bool
+	}{
+		{NewDispatch("class"), true},
+		{&Dispatch{Mode: DispatchProximity, MaxSkips: 3, MaxDelayMinutes: 5}, true},
+		{&Dispatch{Mode: DispatchProximity, MaxSkips: 3}, false},
+		{&Dispatch{Mode: DispatchProximity, MaxDelayMinutes: 5}, false},
+		{&Dispatch{Mode: "random"}, false},
+		{&Dispatch{FallbackMinutes: -1}, false},
+		{&Dispatch{FallbackMinutes: MaxDispatchMinutes + 1}, false},
+		{&Dispatch{Mode: DispatchProximity, MaxSkips: 3, MaxDelayMinutes: MaxDispatchMinutes + 1}, false},
+	}
+
+	for _, c := range cases {
+		if err := c.dispatch.Validate(); (err == nil) != c.valid {
+			t.Errorf("%+v: expected valid to be %v, got %v", c.dispatch, c.valid, err)
+		}
+	}
+}
+
+func TestDispatch_Validate_Defaults(t *testing.T) {
+	d := &Dispatch{}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Mode != DispatchInLine || d.FallbackMinutes != DefaultFallbackMinutes {
+		t.Errorf("expected %v after %v minutes, got %v after %v", DispatchInLine, DefaultFallbackMinutes, d.Mode, d.FallbackMinutes)
+	}
+
+	// a dispatch saved without a fallback does not make every student overdue
+	now := time.Now()
+	queue := &QuestionQueue{Queue: []*Question{
+		{ID: "a", Topic: "loops", CreatedAt: now.Add(-time.Minute)},
+		{ID: "b", Topic: "pointers", CreatedAt: now},
+	}}
+	if next, _ := (&Dispatch{}).Next(queue, &Duty{Topics: []string{"pointers"}}, now); next.ID != "b" {
+		t.Errorf("expected b, got %v", next.ID)
+	}
+}
+
+func TestDuty_ValidateTopics(t *testing.T) {
+	class := &Class{Code: "343", Type: []string{"recursion", "pointers"}}
+
+	if err := (&Duty{Topics: []string{"pointers"}}).ValidateTopics(class); err != nil {
+		t.Errorf("expected a topic of the class to be valid, got %v", err)
+	}
+	if err := (&Duty{Topics: []string{"pointers", "cooking"}}).ValidateTopics(class); err != ErrUnknownTopic {
+		t.Errorf("expected %v, got %v", ErrUnknownTopic, err)
+	}
+}
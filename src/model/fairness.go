@@ -0,0 +1,93 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// FairnessReport is every time a TA/teacher helped a student of a class within [From, To]
+// while someone ahead of them in line was still waiting.
+type FairnessReport struct {
+	Class string    `json:"class"`
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	// students claimed within the range, and how many of them were helped out of order
+	Claims     int `json:"claims"`
+	OutOfOrder int `json:"out_of_order"`
+	// students passed over by every TA/teacher who passed someone over
+	ByTeacher map[string]int    `json:"by_teacher"`
+	Skips     []*OutOfOrderHelp `json:"skips"`
+}
+
+// OutOfOrderHelp is a student still waiting when a TA/teacher claimed someone behind them in line.
+type OutOfOrderHelp struct {
+	StudentID string    `json:"student_id"`
+	HelpedID  string    `json:"helped_id"`
+	TeacherID string    `json:"teacher_id"`
+	At        time.Time `json:"at"`
+	// seconds the student lined up before the student helped, negative if the policy put them ahead though
+	// they lined up after, and had waited by then, to the millisecond
+	Earlier float64 `json:"earlier"`
+	Waited  float64 `json:"waited"`
+	// the student was on hold, so passing them over was expected
+	Held bool `json:"held"`
+}
+
+// MaxFairnessRange bounds the time range of a fairness report.
+const MaxFairnessRange = 7 * 24 * time.Hour
+
+// NewFairnessReport replays the events of the queue of a class, which must start early enough to include
+// everyone waiting at `from`, and reports the students passed over by the claims within [from, to].
+// A student lines up when they ask, or when a TA/teacher moves them in line; the line is ordered
+// by the policy in force at the time of the claim.
+func NewFairnessReport(class string, events []*QueueEvent, from, to time.Time) *FairnessReport {
+	report := &FairnessReport{Class: class, From: from, To: to, ByTeacher: map[string]int{}, Skips: []*OutOfOrderHelp{}}
+
+	r := newReplay(class)
+	for _, e := range sortEvents(events) {
+		helped, ok := r.entries[e.StudentID]
+		claimed := ok && e.Class == class && e.Question != nil &&
+			helped.question.CurrentStatus() == StatusWaiting && e.Question.CurrentStatus() == StatusClaimed
+		if !claimed || e.At.Before(from) || e.At.After(to) {
+			r.apply(e)
+			continue
+		}
+
+		// the line the student was claimed from
+		line := r.queue(e.At)
+		score := helped.score
+		r.apply(e)
+
+		report.Claims++
+		var skips []*OutOfOrderHelp
+		for _, other := range line.Queue {
+			if other.ID == e.StudentID {
+				break
+			}
+			otherScore := r.entries[other.ID].score
+			skips = append(skips, &OutOfOrderHelp{
+				StudentID: other.ID,
+				HelpedID:  e.StudentID,
+				TeacherID: e.TeacherID,
+				At:        e.At,
+				Earlier:   math.Round(score-otherScore) / 1e3,
+				Waited:    e.At.Sub(scoreTime(otherScore)).Round(time.Millisecond).Seconds(),
+				Held:      other.IsHeld(e.At),
+			})
+		}
+		if len(skips) == 0 {
+			continue
+		}
+
+		report.OutOfOrder++
+		report.ByTeacher[e.TeacherID] += len(skips)
+		report.Skips = append(report.Skips, skips...)
+	}
+
+	return report
+}
+
+// scoreTime returns the time a question lined up at a given score, see `Question.QueueScore`.
+func scoreTime(score float64) time.Time {
+	return time.Unix(0, int64(score*1e6))
+}
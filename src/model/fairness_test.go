@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewFairnessReport(t *testing.T) {
+	start := time.Now().Truncate(time.Millisecond)
+	question := func(id, status string, asked int) *Question {
+		q := &Question{ID: id, Class: "201", Status: status, CreatedAt: start.Add(time.Duration(asked) * time.Minute)}
+		if id == "b" {
+			q.HeldUntil = start.Add(time.Hour)
+		}
+		return q
+	}
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	events := []*QueueEvent{
+		{Seq: 1, Class: "201", StudentID: "a", Question: question("a", StatusWaiting, 0), At: at(0)},
+		{Seq: 2, Class: "201", StudentID: "b", Question: question("b", StatusWaiting, 1), At: at(1)},
+		{Seq: 3, Class: "201", StudentID: "c", Question: question("c", StatusWaiting, 2), At: at(2)},
+		{Seq: 4, Class: "330", StudentID: "x", Question: &Question{ID: "x", Class: "330", Status: StatusClaimed}, At: at(3)},
+		// in order
+		{Seq: 5, Class: "201", StudentID: "a", TeacherID: "t1", Question: question("a", StatusClaimed, 0), At: at(4)},
+		// passes over b, who is on hold
+		{Seq: 6, Class: "201", StudentID: "c", TeacherID: "t2", Question: question("c", StatusClaimed, 2), At: at(5)},
+		// released back in its place, then d joins and is claimed ahead of b and c
+		{Seq: 7, Class: "201", StudentID: "c", Question: question("c", StatusWaiting, 2), At: at(6)},
+		{Seq: 8, Class: "201", StudentID: "d", Question: question("d", StatusWaiting, 7), At: at(7)},
+		{Seq: 9, Class: "201", StudentID: "d", TeacherID: "t2", Question: question("d", StatusClaimed, 7), At: at(8)},
+		// outside the range
+		{Seq: 10, Class: "201", StudentID: "c", TeacherID: "t1", Question: question("c", StatusClaimed, 2), At: at(20)},
+	}
+
+	report := NewFairnessReport("201", events, at(3), at(10))
+
+	if report.Claims != 3 {
+		t.Errorf("expected 3 claims, got %v", report.Claims)
+	}
+	if report.OutOfOrder != 2 {
+		t.Errorf("expected 2 out of order, got %v", report.OutOfOrder)
+	}
+	if report.ByTeacher["t2"] != 3 || len(report.ByTeacher) != 1 {
+		t.Errorf("expected 3 students passed over by t2 only, got %v", report.ByTeacher)
+	}
+
+	expected := []struct {
+		student, helped string
+		earlier, waited float64
+		held            bool
+	}{
+		{"b", "c", 60, 240, true},
+		{"b", "d", 360, 420, true},
+		{"c", "d", 300, 360, false},
+	}
+	if len(report.Skips) != len(expected) {
+		t.Fatalf("expected %v skips, got %v", len(expected), len(report.Skips))
+	}
+	for i, e := range expected {
+		s := report.Skips[i]
+		if s.StudentID != e.student || s.HelpedID != e.helped || s.Earlier != e.earlier || s.Waited != e.waited || s.Held != e.held {
+			t.Errorf("skip %v: expected %+v, got %+v", i, e, *s)
+		}
+	}
+}
+
+func TestNewFairnessReport_Policy(t *testing.T) {
+	start := time.Now().Truncate(time.Millisecond)
+	question := func(id, status string, asked, prior int) *Question {
+		return &Question{ID: id, Class: "201", Status: status, PriorQuestions: prior, CreatedAt: start.Add(time.Duration(asked) * time.Minute)}
+	}
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	events := []*QueueEvent{
+		{Seq: 1, Class: "201", Policy: &Policy{Class: "201", Name: PolicyFirstOfSession}, At: at(0)},
+		{Seq: 2, Class: "201", StudentID: "a", Question: question("a", StatusWaiting, 0, 1), At: at(0)},
+		{Seq: 3, Class: "201", StudentID: "b", Question: question("b", StatusWaiting, 1, 0), At: at(1)},
+		// the policy puts b ahead of a
+		{Seq: 4, Class: "201", StudentID: "b", TeacherID: "t1", Question: question("b", StatusClaimed, 1, 0), At: at(2)},
+		{Seq: 5, Class: "201", StudentID: "c", Question: question("c", StatusWaiting, 3, 0), At: at(3)},
+		// passes over c, who the policy put ahead though they lined up after
+		{Seq: 6, Class: "201", StudentID: "a", TeacherID: "t1", Question: question("a", StatusClaimed, 0, 1), At: at(4)},
+	}
+
+	report := NewFairnessReport("201", events, at(0), at(10))
+
+	if report.Claims != 2 || report.OutOfOrder != 1 {
+		t.Errorf("expected 2 claims and 1 out of order, got %v and %v", report.Claims, report.OutOfOrder)
+	}
+	if len(report.Skips) != 1 {
+		t.Fatalf("expected 1 skip, got %v", len(report.Skips))
+	}
+	if s := report.Skips[0]; s.StudentID != "c" || s.HelpedID != "a" || s.Earlier != -180 || s.Waited != 60 {
+		t.Errorf("expected c passed over for a 180 seconds after a lined up, got %+v", *s)
+	}
+}
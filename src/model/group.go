@@ -0,0 +1,100 @@
+package model
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// MaxGroupSize is the most students a TA/teacher can help at once.
+const MaxGroupSize = 8
+
+var (
+	ErrInvalidGroup  = errors.New("a group needs between 2 and 8 different students")
+	ErrGroupNotFound = errors.New("the class has no such group")
+	ErrNotInGroup    = errors.New("the student is not in the group")
+)
+
+// HelpGroup is students of a class a TA/teacher helps together, usually on the same topic;
+// the group goes through the statuses of its questions as one.
+type HelpGroup struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Class     string             `json:"class"`
+	Topic     string             `json:"topic"`
+	TeacherID string             `json:"teacher_id"`
+	// where in the lab the students meet the TA/teacher, e.g. "whiteboard by the door"
+	Location string `json:"location"`
+	// IDs of the students in the group
+	Members []string `json:"members"`
+	// status of the questions of the group
+	Status     string    `json:"status"`
+	ClaimedAt  time.Time `json:"claimed_at"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// GroupSuggestion is students waiting in line of a class with questions on the same topic.
+type GroupSuggestion struct {
+	Topic string `json:"topic"`
+	// IDs of the students, in line order
+	Members []string `json:"members"`
+}
+
+// Validate checks the members of a group a TA/teacher asks for.
+func (g *HelpGroup) Validate() error {
+	if len(g.Members) < 2 || len(g.Members) > MaxGroupSize {
+		return ErrInvalidGroup
+	}
+	seen := make(map[string]bool)
+	for _, id := range g.Members {
+		if len(id) == 0 || seen[id] {
+			return ErrInvalidGroup
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// SuggestGroups returns, for every topic at least two students waiting in line have questions on at a given time,
+// the first `MaxGroupSize` of them, in the order the first of them is in line; students on hold are left out.
+func SuggestGroups(queue *QuestionQueue, at time.Time) []*GroupSuggestion {
+	byTopic := make(map[string]*GroupSuggestion)
+	var topics []*GroupSuggestion
+	for _, q := range queue.Queue {
+		if q.IsHeld(at) || len(q.Topic) == 0 {
+			continue
+		}
+		s, ok := byTopic[q.Topic]
+		if !ok {
+			s = &GroupSuggestion{Topic: q.Topic}
+			byTopic[q.Topic] = s
+			topics = append(topics, s)
+		}
+		if len(s.Members) < MaxGroupSize {
+			s.Members = append(s.Members, q.ID)
+		}
+	}
+
+	suggestions := []*GroupSuggestion{}
+	for _, s := range topics {
+		if len(s.Members) >= 2 {
+			suggestions = append(suggestions, s)
+		}
+	}
+	return suggestions
+}
+
+// JoinGroup puts the question in a group, or takes it out of its group if `g` is nil.
+func (q *Question) JoinGroup(g *HelpGroup) {
+	if g == nil {
+		q.GroupID = primitive.NilObjectID
+		q.GroupLocation = ""
+		return
+	}
+	q.GroupID = g.ID
+	q.GroupLocation = g.Location
+}
+
+// InGroup reports whether the question is helped as part of a group.
+func (q *Question) InGroup() bool {
+	return !q.GroupID.IsZero()
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestSuggestGroups(t *testing.T) {
+	now := time.Now()
+
+	queue := &QuestionQueue{Queue: []*Question{
+		{ID: "a", Topic: "pointers"},
+		{ID: "b", Topic: "recursion"},
+		{ID: "c", Topic: "recursion", HeldUntil: now.Add(time.Minute)},
+		{ID: "d", Topic: "loops"},
+		{ID: "e", Topic: "recursion"},
+		{ID: "f", Topic: "pointers"},
+	}}
+
+	suggestions := SuggestGroups(queue, now)
+	got := ""
+	for _, s := range suggestions {
+		got += s.Topic + ":"
+		for _, id := range s.Members {
+			got += id
+		}
+		got += " "
+	}
+	if expected := "pointers:af recursion:be "; got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	for i := 0; i < MaxGroupSize; i++ {
+		queue.Queue = append(queue.Queue, &Question{ID: "x", Topic: "pointers"})
+	}
+	if s := SuggestGroups(queue, now); len(s[0].Members) != MaxGroupSize {
+		t.Errorf("expected at most %v students, got %v", MaxGroupSize, len(s[0].Members))
+	}
+}
+
+func TestHelpGroup_Validate(t *testing.T) {
+	cases := []struct {
+		members []string
+		valid   bool
+	}{
+		{[]string{"a", "b"}, true},
+		{[]string{"a"}, false},
+		{[]string{"a", "a"}, false},
+		{[]string{"a", ""}, false},
+		{[]string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}, false},
+	}
+
+	for _, c := range cases {
+		if err := (&HelpGroup{Members: c.members}).Validate(); (err == nil) != c.valid {
+			t.Errorf("%v: expected valid to be %v, got %v", c.members, c.valid, err)
+		}
+	}
+}
+
+func TestQuestion_JoinGroup(t *testing.T) {
+	g := &HelpGroup{ID: primitive.NewObjectID(), Location: "whiteboard"}
+	q := &Question{Status: StatusWaiting}
+
+	if err := q.Transition(StatusClaimed, "teacher", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	q.JoinGroup(g)
+	if !q.InGroup() || q.GroupLocation != "whiteboard" {
+		t.Errorf("expected the question in the group at the whiteboard, got %v at %q", q.GroupID, q.GroupLocation)
+	}
+
+	// released students leave the group
+	if err := q.Transition(StatusWaiting, "teacher", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if q.InGroup() || len(q.GroupLocation) != 0 {
+		t.Errorf("expected the question out of the group, got %v at %q", q.GroupID, q.GroupLocation)
+	}
+}
+
+func TestSummarize_Groups(t *testing.T) {
+	group := primitive.NewObjectID()
+
+	summary := Summarize([]*Question{
+		{Status: StatusResolved, GroupID: group},
+		{Status: StatusResolved, GroupID: group},
+		{Status: StatusResolved, GroupID: group},
+		{Status: StatusResolved},
+		{Status: StatusWithdrawn},
+	})
+	if summary.Helps != 2 || summary.Groups != 1 {
+		t.Errorf("expected 2 helps and 1 group, got %v and %v", summary.Helps, summary.Groups)
+	}
+}
+
+func TestQuestionQueue_GetStudentPositions_Group(t *testing.T) {
+	g := &HelpGroup{ID: primitive.NewObjectID(), Location: "whiteboard"}
+	a, b := &Question{ID: "a", Status: StatusClaimed}, &Question{ID: "b", Status: StatusClaimed}
+	a.JoinGroup(g)
+	b.JoinGroup(g)
+
+	queue := &QuestionQueue{
+		Queue:   []*Question{{ID: "c"}},
+		Helping: []*Question{a, b, {ID: "d", Status: StatusClaimed}},
+	}
+
+	positions := queue.GetStudentPositions()
+	if group := positions["a"].Group; group == nil || group.Location != "whiteboard" || group.Size != 2 {
+		t.Errorf("expected a in a group of 2 at the whiteboard, got %+v", group)
+	}
+	if positions["c"].Group != nil || positions["d"].Group != nil {
+		t.Errorf("expected c and d out of any group")
+	}
+}
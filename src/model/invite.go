@@ -0,0 +1,112 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/badoux/checkmail"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"strings"
+	"time"
+)
+
+// InviteLifetime is how long an invite can be used to register once made.
+const InviteLifetime = 7 * 24 * time.Hour
+
+const inviteTokenLength = 32
+
+var (
+	ErrInvalidInvite = errors.New("invalid, used, revoked or expired invite, or for another email address")
+	ErrInviteRole    = errors.New("only admins can invite instructors and admins")
+)
+
+// Invite lets the TA/teacher with an email register once, before it expires, with a role and,
+// if given, on the staff of a class. Only the hash of its token is kept; see `NewInviteToken`.
+type Invite struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Email     string             `json:"email"`
+	Role      string             `json:"role"`
+	Class     string             `json:"class,omitempty"`
+	TokenHash string             `json:"-"`
+	CreatedBy string             `json:"created_by"`
+	CreatedAt time.Time          `json:"created_at"`
+	ExpiresAt time.Time          `json:"expires_at"`
+	UsedAt    time.Time          `json:"used_at"`
+	RevokedAt time.Time          `json:"revoked_at"`
+}
+
+// IssuedInvite is an invite just made, along with its token, only ever sent in this response.
+type IssuedInvite struct {
+	*Invite
+	Token string `json:"token"`
+}
+
+// NewInvite returns an invite for the email of `ni` that the TA/teacher `creator` with the account role
+// `creatorRole` makes at a given time; only admins can invite instructors and admins.
+func NewInvite(ni *Invite, creator, creatorRole string, at time.Time) (*IssuedInvite, error) {
+	if err := checkmail.ValidateFormat(ni.Email); err != nil {
+		return nil, errors.New("invalid email")
+	}
+	if len(ni.Role) == 0 {
+		ni.Role = RoleTA
+	}
+	if !ValidateRole(ni.Role) {
+		return nil, ErrInvalidRole
+	}
+	if ni.Role != RoleTA && creatorRole != RoleAdmin {
+		return nil, ErrInviteRole
+	}
+	if len(ni.Class) != 0 && !ValidateClass(ni.Class) {
+		return nil, errors.New("invalid class code")
+	}
+
+	token, hash, err := NewInviteToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &IssuedInvite{
+		Invite: &Invite{
+			ID:        primitive.NewObjectID(),
+			Email:     strings.ToLower(ni.Email),
+			Role:      ni.Role,
+			Class:     ni.Class,
+			TokenHash: hash,
+			CreatedBy: creator,
+			CreatedAt: at,
+			ExpiresAt: at.Add(InviteLifetime),
+		},
+		Token: token,
+	}, nil
+}
+
+// NewInviteToken returns a new secret invite token and the hash of it that is kept.
+func NewInviteToken() (string, string, error) {
+	b := make([]byte, inviteTokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.URLEncoding.EncodeToString(b)
+	return token, HashInviteToken(token), nil
+}
+
+// HashInviteToken returns the hash of an invite token.
+func HashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsPending reports whether the invite can still be used at a given time.
+func (i *Invite) IsPending(at time.Time) bool {
+	return i.UsedAt.IsZero() && i.RevokedAt.IsZero() && at.Before(i.ExpiresAt)
+}
+
+// StaffRole returns the role the invited TA/teacher gets on the staff of the class of the invite.
+func (i *Invite) StaffRole() string {
+	if i.Role == RoleTA {
+		return RoleTA
+	}
+	return RoleInstructor
+}
@@ -0,0 +1,62 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewInvite(t *testing.T) {
+	at := time.Now()
+
+	cases := []struct {
+		name        string
+		invite      Invite
+		creatorRole string
+		valid       bool
+	}{
+		{"TA by an instructor", Invite{Email: "TA@uw.edu"}, RoleInstructor, true},
+		{"TA of a class", Invite{Email: "ta@uw.edu", Class: "343"}, RoleInstructor, true},
+		{"Instructor by an instructor", Invite{Email: "ta@uw.edu", Role: RoleInstructor}, RoleInstructor, false},
+		{"Instructor by an admin", Invite{Email: "ta@uw.edu", Role: RoleInstructor}, RoleAdmin, true},
+		{"Invalid role", Invite{Email: "ta@uw.edu", Role: "owner"}, RoleAdmin, false},
+		{"Invalid email", Invite{Email: "ta"}, RoleAdmin, false},
+		{"Invalid class", Invite{Email: "ta@uw.edu", Class: "999"}, RoleAdmin, false},
+	}
+
+	for _, c := range cases {
+		ni := c.invite
+		invite, err := NewInvite(&ni, "creator", c.creatorRole, at)
+		if (err == nil) != c.valid {
+			t.Errorf("%v: expected valid to be %v, got %v", c.name, c.valid, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if invite.Email != "ta@uw.edu" || len(invite.Role) == 0 {
+			t.Errorf("%v: expected a lowercase email and a role, got %q and %q", c.name, invite.Email, invite.Role)
+		}
+		if HashInviteToken(invite.Token) != invite.TokenHash {
+			t.Errorf("%v: expected the hash of the token to be kept", c.name)
+		}
+		if !invite.IsPending(at) || invite.IsPending(at.Add(InviteLifetime)) {
+			t.Errorf("%v: expected the invite to be pending until it expires", c.name)
+		}
+	}
+}
+
+func TestInvite_IsPending(t *testing.T) {
+	at := time.Now()
+	invite := Invite{ExpiresAt: at.Add(time.Hour)}
+	if !invite.IsPending(at) {
+		t.Errorf("expected a new invite to be pending")
+	}
+
+	used := invite
+	used.UsedAt = at
+	revoked := invite
+	revoked.RevokedAt = at
+	if used.IsPending(at) || revoked.IsPending(at) {
+		t.Errorf("expected used and revoked invites not to be pending")
+	}
+}
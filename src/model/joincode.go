@@ -0,0 +1,125 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// DefaultJoinRotationMinutes is how often the join code of a class changes when a TA/teacher does not say.
+const DefaultJoinRotationMinutes = 5
+
+const (
+	joinCodeLength = 6
+	// no 0/O, 1/I/L, so a code read off a projector is not mistyped
+	joinCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	joinSecretLength = 32
+)
+
+var (
+	ErrInvalidJoinCode     = errors.New("invalid or expired join code")
+	ErrInvalidJoinSettings = errors.New("invalid join settings")
+)
+
+// JoinSettings is how the join codes students line up in the queue of a class with are made;
+// a code is derived from the secret and the time, and changes every `RotationMinutes`.
+type JoinSettings struct {
+	Class           string    `json:"class"`
+	Secret          []byte    `json:"secret"`
+	RotationMinutes int       `json:"rotation_minutes"`
+	CreatedBy       string    `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// JoinCode is the current join code of a class, shown to students, and the link to join with it.
+type JoinCode struct {
+	Class           string    `json:"class"`
+	Code            string    `json:"code"`
+	Link            string    `json:"link"`
+	RotationMinutes int       `json:"rotation_minutes"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// NewJoinSettings returns join settings of a class with a new secret; codes of earlier settings no longer work.
+func NewJoinSettings(class, teacherID string, rotationMinutes int) (*JoinSettings, error) {
+	if rotationMinutes == 0 {
+		rotationMinutes = DefaultJoinRotationMinutes
+	}
+
+	s := &JoinSettings{
+		Class:           class,
+		Secret:          make([]byte, joinSecretLength),
+		RotationMinutes: rotationMinutes,
+		CreatedBy:       teacherID,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(s.Secret); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate checks that codes change at least once a day.
+func (s *JoinSettings) Validate() error {
+	if s.RotationMinutes < 1 || s.RotationMinutes > 24*60 {
+		return ErrInvalidJoinSettings
+	}
+	return nil
+}
+
+// Code returns the join code of a class at a given time, without its link.
+func (s *JoinSettings) Code(at time.Time) *JoinCode {
+	window := s.window(at)
+	return &JoinCode{
+		Class:           s.Class,
+		Code:            s.code(window),
+		RotationMinutes: s.RotationMinutes,
+		ExpiresAt:       time.Unix(0, 0).Add(time.Duration(window+1) * s.rotation()),
+	}
+}
+
+// Verify reports whether a code is the join code of a class at a given time; the code before it still works,
+// so a student who read it just before it changed can still line up. Codes are not case sensitive.
+func (s *JoinSettings) Verify(code string, at time.Time) bool {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != joinCodeLength {
+		return false
+	}
+
+	window := s.window(at)
+	valid := 0
+	for _, w := range []int64{window, window - 1} {
+		valid |= subtle.ConstantTimeCompare([]byte(code), []byte(s.code(w)))
+	}
+	return valid == 1
+}
+
+func (s *JoinSettings) rotation() time.Duration {
+	return time.Duration(s.RotationMinutes) * time.Minute
+}
+
+// window returns the number of the rotation a time is in.
+func (s *JoinSettings) window(at time.Time) int64 {
+	return at.UnixNano() / int64(s.rotation())
+}
+
+// code returns the join code of a rotation, from the HMAC of its number.
+func (s *JoinSettings) code(window int64) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	_ = binary.Write(mac, binary.BigEndian, window)
+	sum := mac.Sum(nil)
+
+	code := make([]byte, joinCodeLength)
+	for i := range code {
+		code[i] = joinCodeAlphabet[int(sum[i])%len(joinCodeAlphabet)]
+	}
+	return string(code)
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJoinSettings_Verify(t *testing.T) {
+	settings, err := NewJoinSettings("343", "teacher", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	code := settings.Code(now)
+	if len(code.Code) != joinCodeLength || !code.ExpiresAt.After(now) || code.ExpiresAt.Sub(now) > 5*time.Minute {
+		t.Fatalf("unexpected join code %+v at %v", code, now)
+	}
+
+	cases := []struct {
+		name  string
+		code  string
+		at    time.Time
+		valid bool
+	}{
+		{"Current", code.Code, now, true},
+		{"Lower case", strings.ToLower(code.Code), now, true},
+		{"Just changed", code.Code, code.ExpiresAt, true},
+		{"Expired", code.Code, code.ExpiresAt.Add(5 * time.Minute), false},
+		{"Not yet", code.Code, code.ExpiresAt.Add(-10 * time.Minute), false},
+		{"Empty", "", now, false},
+		{"Wrong", "AAAAAA", now, code.Code == "AAAAAA"},
+	}
+
+	for _, c := range cases {
+		if valid := settings.Verify(c.code, c.at); valid != c.valid {
+			t.Errorf("%v: expected valid to be %v, got %v", c.name, c.valid, valid)
+		}
+	}
+
+	// new settings make the codes of the old ones stop working
+	other, err := NewJoinSettings("343", "teacher", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Code(now).Code == code.Code {
+		t.Skip("new settings happen to give the same code")
+	}
+	if other.Verify(code.Code, now) {
+		t.Errorf("expected a code of the old settings to be invalid")
+	}
+}
+
+func TestNewJoinSettings_Invalid(t *testing.T) {
+	for _, minutes := range []int{-1, 24*60 + 1} {
+		if _, err := NewJoinSettings("343", "teacher", minutes); err != ErrInvalidJoinSettings {
+			t.Errorf("%v minutes: expected %v, got %v", minutes, ErrInvalidJoinSettings, err)
+		}
+	}
+
+	settings, err := NewJoinSettings("343", "teacher", 0)
+	if err != nil || settings.RotationMinutes != DefaultJoinRotationMinutes {
+		t.Errorf("expected %v minutes by default, got %+v, %v", DefaultJoinRotationMinutes, settings, err)
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+var (
+	ErrLabSessionOpen     = errors.New("the class already has an open lab session")
+	ErrLabSessionNotFound = errors.New("the class has no open lab session")
+)
+
+// LabSession is one lab meeting or office-hour session of a class;
+// every question asked while it is open belongs to it.
+type LabSession struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id"`
+	Class string             `json:"class"`
+	Room  string             `json:"room"`
+	// IDs of the TA/teachers on duty
+	Staff    []string  `json:"staff"`
+	OpenedAt time.Time `json:"opened_at"`
+	ClosedAt time.Time `json:"closed_at"`
+	// what happened in the session, once it is closed
+	Summary *LabSummary `json:"summary,omitempty"`
+}
+
+// LabSummary sums up the questions asked in a lab session.
+type LabSummary struct {
+	Questions int `json:"questions"`
+	// number of questions asked per topic
+	Topics map[string]int `json:"topics"`
+	// median seconds from a question being asked to a TA/teacher claiming it
+	MedianWait float64 `json:"median_wait"`
+	// questions that were not resolved, e.g. withdrawn, no-shows or left when the session closed
+	Unanswered int `json:"unanswered"`
+	// resolved questions, with every group helped together counting once, and how many of those were groups
+	Helps  int `json:"helps"`
+	Groups int `json:"groups"`
+}
+
+// IsOpen reports whether questions asked now belong to the lab session.
+func (s *LabSession) IsOpen() bool {
+	return s.ClosedAt.IsZero()
+}
+
+// Summarize sums up the questions asked in a lab session.
+func Summarize(questions []*Question) *LabSummary {
+	summary := &LabSummary{Questions: len(questions), Topics: make(map[string]int)}
+
+	var waits []float64
+	groups := make(map[primitive.ObjectID]bool)
+	for _, q := range questions {
+		summary.Topics[q.Topic]++
+		if q.CurrentStatus() != StatusResolved {
+			summary.Unanswered++
+		} else if !q.InGroup() {
+			summary.Helps++
+		} else if !groups[q.GroupID] {
+			groups[q.GroupID] = true
+			summary.Helps++
+			summary.Groups++
+		}
+		if !q.ClaimedAt.IsZero() {
+			waits = append(waits, q.ClaimedAt.Sub(q.CreatedAt).Seconds())
+		}
+	}
+
+	summary.MedianWait = median(waits)
+	return summary
+}
+
+// median returns the median of some values, or 0 if there are none.
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
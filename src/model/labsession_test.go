@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Now()
+
+	questions := []*Question{
+		{Topic: "recursion", Status: StatusResolved, CreatedAt: start, ClaimedAt: start.Add(60 * time.Second)},
+		{Topic: "recursion", Status: StatusResolved, CreatedAt: start, ClaimedAt: start.Add(180 * time.Second)},
+		{Topic: "pointers", Status: StatusNoShow, CreatedAt: start, ClaimedAt: start.Add(300 * time.Second)},
+		{Topic: "pointers", Status: StatusWithdrawn, CreatedAt: start},
+		{Topic: "pointers", Status: StatusArchived, CreatedAt: start},
+	}
+
+	summary := Summarize(questions)
+	if summary.Questions != 5 {
+		t.Errorf("expected 5 questions, got %v", summary.Questions)
+	}
+	if summary.Topics["recursion"] != 2 || summary.Topics["pointers"] != 3 {
+		t.Errorf("expected 2 recursion and 3 pointers questions, got %v", summary.Topics)
+	}
+	if summary.MedianWait != 180 {
+		t.Errorf("expected a median wait of 180 seconds, got %v", summary.MedianWait)
+	}
+	if summary.Unanswered != 3 {
+		t.Errorf("expected 3 unanswered questions, got %v", summary.Unanswered)
+	}
+
+	if empty := Summarize(nil); empty.Questions != 0 || empty.MedianWait != 0 {
+		t.Errorf("expected an empty summary, got %v questions and a median wait of %v", empty.Questions, empty.MedianWait)
+	}
+}
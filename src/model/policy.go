@@ -0,0 +1,187 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Ordering policies of a queue; within what a policy puts ahead, students stay in the order they lined up.
+const (
+	// students are helped in the order they lined up
+	PolicyFIFO = "fifo"
+	// students asking their first question of the lab session go ahead of repeat askers
+	PolicyFirstOfSession = "first-of-session"
+	// students helped `HourlyLimit` times or more within the last hour go behind the others
+	PolicyHourlyLimit = "hourly-limit"
+)
+
+// SessionWindow is how far back questions count as asked in the same session when a class has no lab session open.
+const SessionWindow = 4 * time.Hour
+
+var ErrInvalidPolicy = errors.New("invalid ordering policy")
+
+// Policy is how the students waiting in line of a class are ordered.
+// A class that never had a policy set is FIFO.
+type Policy struct {
+	Class string `json:"class"`
+	Name  string `json:"name"`
+	// times a student can be helped within an hour before going behind the others, for PolicyHourlyLimit
+	HourlyLimit int `json:"hourly_limit,omitempty"`
+}
+
+// PolicyMove is a student the policy of a queue moved away from their place in line.
+type PolicyMove struct {
+	ID     string `json:"id"`
+	Policy string `json:"policy"`
+	// 1-based positions in line before and after the policy
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// NewPolicy returns the policy of a class that never had one set.
+func NewPolicy(class string) *Policy {
+	return &Policy{Class: class, Name: PolicyFIFO}
+}
+
+// Validate checks the fields of a policy a TA/teacher can set.
+func (p *Policy) Validate() error {
+	switch p.Name {
+	case PolicyFIFO, PolicyFirstOfSession:
+	case PolicyHourlyLimit:
+		if p.HourlyLimit < 1 {
+			return ErrInvalidPolicy
+		}
+	default:
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+// rank returns where a question goes in line under the policy when the line is ordered at a given time;
+// lower ranks are ahead.
+func (p *Policy) rank(q *Question, at time.Time) int {
+	switch p.Name {
+	case PolicyFirstOfSession:
+		if q.PriorQuestions > 0 {
+			return 1
+		}
+	case PolicyHourlyLimit:
+		if q.RecentHelps(at) >= p.HourlyLimit {
+			return 1
+		}
+	}
+	return 0
+}
+
+// rank returns where a question goes in line under the policy of the queue, if it has one.
+func (q *QuestionQueue) rank(question *Question) int {
+	if q.Policy == nil {
+		return 0
+	}
+	return q.Policy.rank(question, q.orderedAt)
+}
+
+// ApplyPolicy orders the students waiting in line by the policy of the queue, if it has one,
+// and records every student it moved in `Moves`.
+func (q *QuestionQueue) ApplyPolicy() {
+	q.Moves = []*PolicyMove{}
+	if q.Policy == nil {
+		return
+	}
+
+	from := make(map[string]int)
+	for i, question := range q.Queue {
+		from[question.ID] = i + 1
+	}
+
+	sort.SliceStable(q.Queue, func(i, j int) bool { return q.rank(q.Queue[i]) < q.rank(q.Queue[j]) })
+
+	for i, question := range q.Queue {
+		if from[question.ID] != i+1 {
+			q.Moves = append(q.Moves, &PolicyMove{question.ID, q.Policy.Name, from[question.ID], i + 1})
+		}
+	}
+}
+
+// PlaceInLine returns the score that moves the student `id` waiting in line to a 1-based position in the line
+// ordered by the policy, given the scores of the students in line, and the position the student ends up at:
+// the policy still puts the students it ranks differently ahead of or behind them, and positions past
+// the end move them to the back. It returns false if the student is not waiting in line.
+func (q *QuestionQueue) PlaceInLine(id string, position int, scores map[string]float64) (int, float64, bool) {
+	var student *Question
+	for _, question := range q.Queue {
+		if question.ID == id {
+			student = question
+		}
+	}
+	if student == nil {
+		return 0, 0, false
+	}
+
+	// the policy keeps the students it ranks alike in the order of their scores
+	ahead := 0
+	var alike []float64
+	for _, question := range q.Queue {
+		if question == student {
+			continue
+		}
+		switch rank := q.rank(question); {
+		case rank < q.rank(student):
+			ahead++
+		case rank == q.rank(student):
+			alike = append(alike, scores[question.ID])
+		}
+	}
+
+	sort.Float64s(alike)
+	position -= ahead
+	if position < 1 {
+		position = 1
+	} else if position > len(alike)+1 {
+		position = len(alike) + 1
+	}
+
+	score := scores[id]
+	switch {
+	case len(alike) == 0:
+	case position == 1:
+		score = alike[0] - 1
+	case position == len(alike)+1:
+		score = alike[len(alike)-1] + 1
+	default:
+		score = (alike[position-2] + alike[position-1]) / 2
+	}
+	return ahead + position, score, true
+}
+
+// SetHistory records what the policies need to know about the earlier questions of the student asking a question
+// at a given time: how many they asked in the same lab session and when those resolved within the last hour were.
+// Helps any earlier than that are more than an hour old whenever the question is in line.
+func (q *Question) SetHistory(earlier []*Question, at time.Time) {
+	q.PriorQuestions = 0
+	q.HelpedAt = nil
+	for _, e := range earlier {
+		// the question itself does not count once it is saved
+		if !q.QuestionID.IsZero() && e.QuestionID == q.QuestionID {
+			continue
+		}
+		if e.LabSessionID == q.LabSessionID {
+			q.PriorQuestions++
+		}
+		if e.CurrentStatus() == StatusResolved && e.ResolvedAt.After(at.Add(-time.Hour)) {
+			q.HelpedAt = append(q.HelpedAt, e.ResolvedAt)
+		}
+	}
+}
+
+// RecentHelps returns how many times the student asking the question was helped within the hour before a given time.
+func (q *Question) RecentHelps(at time.Time) int {
+	helps := 0
+	for _, helped := range q.HelpedAt {
+		if helped.After(at.Add(-time.Hour)) && !helped.After(at) {
+			helps++
+		}
+	}
+	return helps
+}
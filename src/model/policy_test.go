@@ -0,0 +1,128 @@
+package model
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestQuestionQueue_ApplyPolicy(t *testing.T) {
+	now := time.Now()
+
+	line := func() []*Question {
+		return []*Question{
+			{ID: "a", PriorQuestions: 2, HelpedAt: []time.Time{now.Add(-50 * time.Minute), now.Add(-10 * time.Minute)}},
+			{ID: "b", PriorQuestions: 0},
+			{ID: "c", PriorQuestions: 1},
+			{ID: "d", PriorQuestions: 0, HelpedAt: []time.Time{now.Add(-5 * time.Minute)}},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		policy   *Policy
+		at       time.Time
+		expected string
+		moves    int
+	}{
+		{"No policy", nil, now, "abcd", 0},
+		{"FIFO", NewPolicy("class"), now, "abcd", 0},
+		{"First of session", &Policy{Name: PolicyFirstOfSession}, now, "bdac", 4},
+		{"Hourly limit", &Policy{Name: PolicyHourlyLimit, HourlyLimit: 2}, now, "bcda", 4},
+		// a's first help is more than an hour old by then
+		{"Hourly limit, later", &Policy{Name: PolicyHourlyLimit, HourlyLimit: 2}, now.Add(20 * time.Minute), "abcd", 0},
+	}
+
+	for _, c := range cases {
+		queue := NewQuestionQueue("class", line(), c.policy, c.at)
+
+		got := ""
+		for _, q := range queue.Queue {
+			got += q.ID
+		}
+		if got != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, got)
+		}
+		if len(queue.Moves) != c.moves {
+			t.Errorf("%v: expected %v moves, got %v", c.name, c.moves, len(queue.Moves))
+		}
+		for _, m := range queue.Moves {
+			if m.From == m.To {
+				t.Errorf("%v: %v was recorded as moved but stayed at %v", c.name, m.ID, m.From)
+			}
+		}
+	}
+}
+
+func TestQuestionQueue_PlaceInLine(t *testing.T) {
+	policy := &Policy{Name: PolicyFirstOfSession}
+
+	cases := []struct {
+		name     string
+		id       string
+		position int
+		expected string
+		placed   int
+	}{
+		// b and d asked their first question, so they are ahead of a and c
+		{"To the front", "c", 1, "bdca", 3},
+		{"To the back", "b", 100, "dbac", 2},
+		{"Within the first askers", "d", 1, "dbac", 1},
+		{"Among the repeat askers", "a", 4, "bdca", 4},
+	}
+
+	for _, c := range cases {
+		line := []*Question{
+			{ID: "a", PriorQuestions: 1},
+			{ID: "b"},
+			{ID: "c", PriorQuestions: 2},
+			{ID: "d"},
+		}
+		scores := map[string]float64{"a": 1, "b": 2, "c": 3, "d": 4}
+
+		placed, score, ok := NewQuestionQueue("class", line, policy, time.Now()).PlaceInLine(c.id, c.position, scores)
+		if !ok {
+			t.Fatalf("%v: expected %v to be placed", c.name, c.id)
+		}
+		if placed != c.placed {
+			t.Errorf("%v: expected %v at %v, got %v", c.name, c.id, c.placed, placed)
+		}
+
+		// the line as redis orders it, by score
+		scores[c.id] = score
+		sort.SliceStable(line, func(i, j int) bool { return scores[line[i].ID] < scores[line[j].ID] })
+		got := ""
+		for _, q := range NewQuestionQueue("class", line, policy, time.Now()).Queue {
+			got += q.ID
+		}
+		if got != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, got)
+		}
+	}
+
+	helping := []*Question{{ID: "a", Status: StatusClaimed}}
+	if _, _, ok := NewQuestionQueue("class", helping, policy, time.Now()).PlaceInLine("a", 1, map[string]float64{"a": 1}); ok {
+		t.Errorf("expected a student being helped not to be placed in line")
+	}
+}
+
+func TestQuestion_SetHistory(t *testing.T) {
+	now := time.Now()
+
+	q := &Question{}
+	q.SetHistory([]*Question{
+		{Status: StatusResolved, ResolvedAt: now.Add(-10 * time.Minute)},
+		{Status: StatusResolved, ResolvedAt: now.Add(-2 * time.Hour)},
+		{Status: StatusWithdrawn, ResolvedAt: now.Add(-5 * time.Minute)},
+	}, now)
+
+	if q.PriorQuestions != 3 {
+		t.Errorf("expected 3 prior questions, got %v", q.PriorQuestions)
+	}
+	if len(q.HelpedAt) != 1 || q.RecentHelps(now) != 1 {
+		t.Errorf("expected 1 recent help, got %v", q.HelpedAt)
+	}
+	if q.RecentHelps(now.Add(time.Hour)) != 0 {
+		t.Errorf("expected no recent helps an hour later, got %v", q.RecentHelps(now.Add(time.Hour)))
+	}
+}
@@ -1,16 +1,185 @@
 package model
 
 import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"time"
 )
 
+// Statuses of a question; a question starts waiting in line, gets claimed by a TA/teacher,
+// who may give it back to the line, start helping, or mark the student as a no-show.
+// Students may withdraw their question at any point while it is in the queue;
+// the questions left when the queue closes are archived.
+const (
+	StatusWaiting    = "waiting"
+	StatusClaimed    = "claimed"
+	StatusInProgress = "in-progress"
+	StatusResolved   = "resolved"
+	StatusNoShow     = "no-show"
+	StatusWithdrawn  = "withdrawn"
+	StatusArchived   = "archived"
+)
+
+var (
+	ErrInvalidTransition = errors.New("the question cannot change to this status")
+	ErrClaimedByOther    = errors.New("the question is claimed by another teacher")
+)
+
+// transitions maps the status of a question to the statuses it can change to.
+var transitions = map[string][]string{
+	StatusWaiting:    {StatusClaimed, StatusWithdrawn, StatusArchived},
+	StatusClaimed:    {StatusWaiting, StatusInProgress, StatusResolved, StatusNoShow, StatusWithdrawn, StatusArchived},
+	StatusInProgress: {StatusResolved, StatusWithdrawn, StatusArchived},
+}
+
 type Question struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Class       string    `json:"class"`
-	Topic       string    `json:"topic"`
-	Description string    `json:"description"`
-	Loc_X       float64   `json:"loc_x" bson:"loc_x"`
-	Loc_Y       float64   `json:"loc_y" bson:"loc_y"`
-	CreatedAt   time.Time `json:"created_at"`
-}
\ No newline at end of file
+	QuestionID  primitive.ObjectID `json:"question_id" bson:"_id"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Class       string             `json:"class"`
+	Topic       string             `json:"topic"`
+	Description string             `json:"description"`
+	Loc_X       float64            `json:"loc_x" bson:"loc_x"`
+	Loc_Y       float64            `json:"loc_y" bson:"loc_y"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Status      string             `json:"status"`
+	ClaimedBy   string             `json:"claimed_by,omitempty"`
+	ClaimedAt   time.Time          `json:"claimed_at"`
+	// when and by whom the question left the queue, its status then being the outcome
+	ResolvedAt time.Time `json:"resolved_at"`
+	ResolvedBy string    `json:"resolved_by,omitempty"`
+	// seconds from the question being claimed to it leaving the queue
+	HelpDuration float64 `json:"help_duration"`
+	// a TA/teacher skipped the student, who keeps their place in line but is not called until then
+	HeldUntil time.Time `json:"held_until"`
+	// times the student was not there when a TA/teacher came to help with this question
+	NoShows int `json:"no_shows"`
+	// the lab session of the class open when the question was asked, if any
+	LabSessionID primitive.ObjectID `json:"lab_session_id"`
+	// questions the student asked earlier in the session, and when their earlier questions were resolved
+	// within the hour before asking, for the ordering policies
+	PriorQuestions int         `json:"prior_questions"`
+	HelpedAt       []time.Time `json:"helped_at"`
+	// times a TA/teacher helped someone behind the student in line, and when they first did
+	Skips     int       `json:"skips"`
+	SkippedAt time.Time `json:"skipped_at"`
+	// the group the student is helped in, if any, and where the group meets
+	GroupID       primitive.ObjectID `json:"group_id"`
+	GroupLocation string             `json:"group_location,omitempty"`
+	// hash of the token the student owns the question with, kept in mongo only so that
+	// a question put back in line still has its owner; see `session.NewOwnerToken`
+	OwnerHash string `json:"-"`
+}
+
+// NewQuestion is a question as a student asks it; everything else about the question,
+// e.g. its status, skips or group, is up to the server.
+type NewQuestion struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Class       string  `json:"class"`
+	Topic       string  `json:"topic"`
+	Description string  `json:"description"`
+	Loc_X       float64 `json:"loc_x"`
+	Loc_Y       float64 `json:"loc_y"`
+}
+
+// ToQuestion returns the question a student asks, before the server fills in the rest.
+func (nq *NewQuestion) ToQuestion() *Question {
+	return &Question{
+		ID:          nq.ID,
+		Name:        nq.Name,
+		Class:       nq.Class,
+		Topic:       nq.Topic,
+		Description: nq.Description,
+		Loc_X:       nq.Loc_X,
+		Loc_Y:       nq.Loc_Y,
+	}
+}
+
+// NoShow counts the times a student was not there when a TA/teacher came to help them in a class.
+type NoShow struct {
+	Class string `json:"class"`
+	ID    string `json:"id"`
+	Count int    `json:"count"`
+}
+
+// Merge overwrites what a student can change about their question with
+// another question of theirs; who asked, in which class and when stay the same.
+func (q *Question) Merge(other *Question) {
+	q.Name = other.Name
+	q.Topic = other.Topic
+	q.Description = other.Description
+	q.Loc_X = other.Loc_X
+	q.Loc_Y = other.Loc_Y
+}
+
+// CurrentStatus returns the status of the question;
+// questions asked before they had a status are waiting.
+func (q *Question) CurrentStatus() string {
+	if len(q.Status) == 0 {
+		return StatusWaiting
+	}
+	return q.Status
+}
+
+// ActiveStatuses returns the statuses of the questions that still belong in the queue.
+func ActiveStatuses() []string {
+	return []string{StatusWaiting, StatusClaimed, StatusInProgress}
+}
+
+// IsActive reports whether the question still belongs in the queue.
+func (q *Question) IsActive() bool {
+	_, ok := transitions[q.CurrentStatus()]
+	return ok
+}
+
+// IsHeld reports whether the student is skipped for now at a given time.
+func (q *Question) IsHeld(at time.Time) bool {
+	return q.HeldUntil.After(at)
+}
+
+// IsHelping reports whether a TA/teacher is on their way to or helping the student.
+func (q *Question) IsHelping() bool {
+	return q.CurrentStatus() == StatusClaimed || q.CurrentStatus() == StatusInProgress
+}
+
+// Transition changes the status of the question on behalf of the TA/teacher with `teacherID`
+// at a given time; only the TA/teacher who claimed a question can change its status,
+// except for the student withdrawing it and the queue closing.
+func (q *Question) Transition(status, teacherID string, at time.Time) error {
+	allowed := false
+	for _, s := range transitions[q.CurrentStatus()] {
+		if s == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidTransition
+	}
+
+	if q.IsHelping() && status != StatusWithdrawn && status != StatusArchived && q.ClaimedBy != teacherID {
+		return ErrClaimedByOther
+	}
+
+	switch status {
+	case StatusWaiting:
+		q.ClaimedBy = ""
+		q.ClaimedAt = time.Time{}
+		q.JoinGroup(nil)
+	case StatusClaimed:
+		q.ClaimedBy = teacherID
+		q.ClaimedAt = at
+	}
+
+	q.Status = status
+
+	if !q.IsActive() {
+		q.ResolvedAt = at
+		q.ResolvedBy = teacherID
+		if !q.ClaimedAt.IsZero() {
+			q.HelpDuration = at.Sub(q.ClaimedAt).Seconds()
+		}
+	}
+	return nil
+}
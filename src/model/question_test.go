@@ -0,0 +1,142 @@
+package model
+
+import (
+	"encoding/json"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+	"time"
+)
+
+func TestQuestion_Transition(t *testing.T) {
+
+	cases := []struct {
+		name      string
+		statuses  []string
+		teachers  []string
+		expected  error
+		claimedBy string
+	}{
+		{
+			name:      "Claim a waiting question",
+			statuses:  []string{StatusClaimed},
+			teachers:  []string{"ta"},
+			claimedBy: "ta",
+		},
+		{
+			name:      "Resolve after helping",
+			statuses:  []string{StatusClaimed, StatusInProgress, StatusResolved},
+			teachers:  []string{"ta", "ta", "ta"},
+			claimedBy: "ta",
+		},
+		{
+			name:     "Release back to the line",
+			statuses: []string{StatusClaimed, StatusWaiting},
+			teachers: []string{"ta", "ta"},
+		},
+		{
+			name:      "Claim a claimed question",
+			statuses:  []string{StatusClaimed, StatusClaimed},
+			teachers:  []string{"ta", "other"},
+			expected:  ErrInvalidTransition,
+			claimedBy: "ta",
+		},
+		{
+			name:      "Resolve a question claimed by another teacher",
+			statuses:  []string{StatusClaimed, StatusResolved},
+			teachers:  []string{"ta", "other"},
+			expected:  ErrClaimedByOther,
+			claimedBy: "ta",
+		},
+		{
+			name:     "Resolve a waiting question",
+			statuses: []string{StatusResolved},
+			teachers: []string{"ta"},
+			expected: ErrInvalidTransition,
+		},
+		{
+			name:      "Student withdraws while claimed",
+			statuses:  []string{StatusClaimed, StatusWithdrawn},
+			teachers:  []string{"ta", ""},
+			claimedBy: "ta",
+		},
+		{
+			name:      "Queue closes while helping",
+			statuses:  []string{StatusClaimed, StatusInProgress, StatusArchived},
+			teachers:  []string{"ta", "ta", ""},
+			claimedBy: "ta",
+		},
+		{
+			name:      "No-show once helping started",
+			statuses:  []string{StatusClaimed, StatusInProgress, StatusNoShow},
+			teachers:  []string{"ta", "ta", "ta"},
+			expected:  ErrInvalidTransition,
+			claimedBy: "ta",
+		},
+	}
+
+	for _, c := range cases {
+		q := &Question{}
+		var err error
+		for i, status := range c.statuses {
+			if err = q.Transition(status, c.teachers[i], time.Now()); err != nil {
+				break
+			}
+		}
+		if err != c.expected {
+			t.Errorf("%v: expected error %v, got %v", c.name, c.expected, err)
+		}
+		if q.ClaimedBy != c.claimedBy {
+			t.Errorf("%v: expected claimed by %q, got %q", c.name, c.claimedBy, q.ClaimedBy)
+		}
+	}
+}
+
+func TestQuestion_Transition_Resolution(t *testing.T) {
+	claimed := time.Now()
+	resolved := claimed.Add(90 * time.Second)
+
+	q := &Question{}
+	if err := q.Transition(StatusClaimed, "ta", claimed); err != nil {
+		t.Fatalf("unexpected error when claiming: %v", err)
+	}
+	if !q.ResolvedAt.IsZero() {
+		t.Errorf("expected a claimed question not to be resolved, got %v", q.ResolvedAt)
+	}
+
+	if err := q.Transition(StatusResolved, "ta", resolved); err != nil {
+		t.Fatalf("unexpected error when resolving: %v", err)
+	}
+	if !q.ResolvedAt.Equal(resolved) || q.ResolvedBy != "ta" || q.HelpDuration != 90 {
+		t.Errorf("expected resolved at %v by ta in 90s, got %v by %v in %vs", resolved, q.ResolvedAt, q.ResolvedBy, q.HelpDuration)
+	}
+}
+
+func TestNewQuestion_ToQuestion(t *testing.T) {
+	// a student cannot set what the server keeps track of about their question
+	body := `{"id": "student", "class": "343", "topic": "recursion", "description": "stack overflow", "loc_x": 1, "loc_y": 2,
+		"status": "in-progress", "claimed_by": "ta", "skips": 99, "skipped_at": "2019-06-03T14:00:00Z",
+		"held_until": "2119-06-03T14:00:00Z", "no_shows": 3, "group_id": "5cf0a2b9e1d4c3a2b1f0e9d8", "group_location": "MGH 430",
+		"resolved_at": "2019-06-03T14:00:00Z", "resolved_by": "ta", "help_duration": 60, "prior_questions": 0, "helped_at": ["2019-06-03T14:00:00Z"]}`
+
+	var nq NewQuestion
+	if err := json.Unmarshal([]byte(body), &nq); err != nil {
+		t.Fatal(err)
+	}
+	q := nq.ToQuestion()
+
+	if q.ID != "student" || q.Class != "343" || q.Topic != "recursion" || q.Description != "stack overflow" || q.Loc_X != 1 || q.Loc_Y != 2 {
+		t.Errorf("expected what the student asked, got %+v", q)
+	}
+	if len(q.Status) != 0 || len(q.ClaimedBy) != 0 || q.Skips != 0 || !q.SkippedAt.IsZero() || !q.HeldUntil.IsZero() || q.NoShows != 0 {
+		t.Errorf("expected no status, claim, skips, hold or no-shows, got %+v", q)
+	}
+	if q.GroupID != primitive.NilObjectID || len(q.GroupLocation) != 0 {
+		t.Errorf("expected no group, got %v in %v", q.GroupID.Hex(), q.GroupLocation)
+	}
+	if !q.ResolvedAt.IsZero() || len(q.ResolvedBy) != 0 || q.HelpDuration != 0 {
+		t.Errorf("expected no resolution, got %+v", q)
+	}
+	if len(q.HelpedAt) != 0 {
+		t.Errorf("expected no help times, got %v", q.HelpedAt)
+	}
+}
@@ -1,28 +1,105 @@
 package model
 
+import "time"
+
 // QuestionQueue will be unmarshalled from the redis store
 // this requires the json the queue receives to be in the format:
 // {
+//		"class": "class_code",
 //		"queue": [
 // 			{ format of question struct }
+//		],
+//		"helping": [
+// 			{ format of question struct }
+//		],
+//		"wait": { format of wait stats struct },
+//		"state": { format of queue state struct },
+//		"policy": { format of policy struct },
+//		"moves": [
+//			{ format of policy move struct }
 //		]
 // }
+// where `queue` holds the students waiting in line, `helping`
+// the students a TA/teacher has claimed, `wait` what their wait is estimated from, if known,
+// `state` whether students can join, `policy` how the line is ordered and `moves` whom it moved.
 type QuestionQueue struct {
-	Queue []*Question `json:"queue"`
+	Class   string        `json:"class"`
+	Queue   []*Question   `json:"queue"`
+	Helping []*Question   `json:"helping"`
+	Wait    *WaitStats    `json:"wait,omitempty"`
+	State   *QueueState   `json:"state,omitempty"`
+	Policy  *Policy       `json:"policy,omitempty"`
+	Moves   []*PolicyMove `json:"moves,omitempty"`
+	// when the line was ordered, which is what the policy counts recent helps back from
+	orderedAt time.Time
+}
+
+// NewQuestionQueue returns the queue of a class from the questions in line, in the order of their scores
+// (see `Question.QueueScore`): the students being helped apart from the students waiting, ordered by the policy
+// of the class, if any, as it stands at a given time. Everything that goes by the order of the line builds it this way.
+func NewQuestionQueue(class string, line []*Question, policy *Policy, at time.Time) *QuestionQueue {
+	queue := &QuestionQueue{Class: class, Queue: []*Question{}, Helping: []*Question{}, Policy: policy, orderedAt: at}
+	for _, q := range line {
+		if q.IsHelping() {
+			queue.Helping = append(queue.Helping, q)
+		} else {
+			queue.Queue = append(queue.Queue, q)
+		}
+	}
+	queue.ApplyPolicy()
+	return queue
 }
 
-// PositionInLine is the position in line for the student map
+// PositionInLine is the position in line for the student map;
+// students being helped are no longer in line and have position 0.
+// Students a TA/teacher skipped for now keep their position until `HeldUntil`.
 type PositionInLine struct {
-	Position    int `json:"position"`
-	QueueLength int `json:"queueLength"`
+	Class       string    `json:"class"`
+	Status      string    `json:"status"`
+	Position    int       `json:"position"`
+	QueueLength int       `json:"queueLength"`
+	HeldUntil   time.Time `json:"heldUntil"`
+	// estimated seconds until a TA/teacher comes to help, see `WaitStats.EstimateWait`
+	EstimatedWait float64 `json:"estimatedWait"`
+	// whether the queue is open, see `QueueState`
+	State *QueueState `json:"state,omitempty"`
+	// the group the student is being helped in, if any
+	Group *GroupHelp `json:"group,omitempty"`
+}
+
+// GroupHelp is where a student being helped in a group meets the TA/teacher and how many students are in it.
+type GroupHelp struct {
+	ID       string `json:"id"`
+	Location string `json:"location"`
+	Size     int    `json:"size"`
 }
 
 // GetStudentPositions will convert the entire queue into a map to get
 // student positions faster.
 func (q *QuestionQueue) GetStudentPositions() map[string]*PositionInLine {
+	wait := q.Wait
+	if wait == nil {
+		wait = NewWaitStats(nil, q.Helping, nil)
+	}
+
+	groupSizes := make(map[string]int)
+	for _, question := range q.Helping {
+		if question.InGroup() {
+			groupSizes[question.GroupID.Hex()]++
+		}
+	}
+
 	studentPositions := make(map[string]*PositionInLine)
 	for i, question := range q.Queue {
-		studentPositions[question.ID] = &PositionInLine{i + 1, len(q.Queue)}
+		studentPositions[question.ID] = &PositionInLine{q.Class, question.CurrentStatus(), i + 1, len(q.Queue), question.HeldUntil, wait.EstimateWait(i + 1), q.State, nil}
+	}
+	for _, question := range q.Helping {
+		position := &PositionInLine{q.Class, question.CurrentStatus(), 0, len(q.Queue), question.HeldUntil, 0, q.State, nil}
+		if question.InGroup() {
+			id := question.GroupID.Hex()
+			position.Group = &GroupHelp{id, question.GroupLocation, groupSizes[id]}
+		}
+		studentPositions[question.ID] = position
 	}
 	return studentPositions
 }
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"sort"
+	"time"
+)
+
+// HistoryWindow is how far back the events of a queue are replayed from to rebuild it at a point in time;
+// every change to a question records it whole, so a question is only left out if it waited that long
+// without any change; queues close after every meeting anyway.
+const HistoryWindow = 24 * time.Hour
+
+// MaxHistoryEvents bounds the events of a queue replayed at once.
+const MaxHistoryEvents = 20000
+
+var ErrTooManyEvents = errors.New("too many events, ask for a shorter time range")
+
+// QueueEvent is an immutable record of a change to a question in the queue of a class,
+// or to how its line is ordered; its type is the type of the `notifier.Message` sent about the change.
+type QueueEvent struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Seq       int64              `json:"seq"`
+	Type      string             `json:"type"`
+	Class     string             `json:"class"`
+	StudentID string             `json:"student_id"`
+	TeacherID string             `json:"teacher_id,omitempty"`
+	Question  *Question          `json:"question"`
+	// place of the question in line when the change set a new one, see `Question.QueueScore`
+	Score float64 `json:"score,omitempty"`
+	// the ordering policy of the queue from then on, when the change set one
+	Policy *Policy   `json:"policy,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// QueueScore returns the score that places a question in line by the time it was asked;
+// lower scores are ahead in line.
+func (q *Question) QueueScore() float64 {
+	return float64(q.CreatedAt.UnixNano()) / 1e6
+}
+
+// ReplayQueue reconstructs the queue of a class by applying events in the order of
+// their sequence numbers, so that replaying the events up to a time gives the queue at that time,
+// ordered by the policy in force then.
+func ReplayQueue(class string, events []*QueueEvent, at time.Time) *QuestionQueue {
+	r := newReplay(class)
+	for _, e := range sortEvents(events) {
+		r.apply(e)
+	}
+	return r.queue(at)
+}
+
+// replay is the queue of a class as the events applied so far left it.
+type replay struct {
+	class   string
+	policy  *Policy
+	entries map[string]*replayEntry
+}
+
+// replayEntry is a question in line and its place in line, see `Question.QueueScore`.
+type replayEntry struct {
+	question *Question
+	score    float64
+}
+
+// newReplay returns the queue of a class before any event.
+func newReplay(class string) *replay {
+	return &replay{class: class, entries: make(map[string]*replayEntry)}
+}
+
+// apply applies an event to the queue; events of other classes are ignored.
+func (r *replay) apply(e *QueueEvent) {
+	if e.Class != r.class {
+		return
+	}
+	if e.Policy != nil {
+		r.policy = e.Policy
+	}
+	if e.Question == nil {
+		return
+	}
+
+	if !e.Question.IsActive() {
+		delete(r.entries, e.StudentID)
+		return
+	}
+
+	current, ok := r.entries[e.StudentID]
+	if !ok {
+		current = &replayEntry{score: e.Question.QueueScore()}
+		r.entries[e.StudentID] = current
+	}
+	current.question = e.Question
+	if e.Score != 0 {
+		current.score = e.Score
+	}
+}
+
+// queue returns the queue as the events applied so far left it, ordered at a given time, see `NewQuestionQueue`.
+func (r *replay) queue(at time.Time) *QuestionQueue {
+	line := make([]*replayEntry, 0, len(r.entries))
+	for _, e := range r.entries {
+		line = append(line, e)
+	}
+	// same order as the redis sorted set: by score, then by student ID
+	sort.Slice(line, func(i, j int) bool {
+		if line[i].score != line[j].score {
+			return line[i].score < line[j].score
+		}
+		return line[i].question.ID < line[j].question.ID
+	})
+
+	questions := make([]*Question, len(line))
+	for i, e := range line {
+		questions[i] = e.question
+	}
+	return NewQuestionQueue(r.class, questions, r.policy, at)
+}
+
+// sortEvents returns a copy of events in the order of their sequence numbers.
+func sortEvents(events []*QueueEvent) []*QueueEvent {
+	sorted := make([]*QueueEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Seq < sorted[j].Seq })
+	return sorted
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReplayQueue(t *testing.T) {
+	start := time.Now()
+	question := func(id, status string, asked int) *Question {
+		return &Question{ID: id, Class: "201", Status: status, CreatedAt: start.Add(time.Duration(asked) * time.Minute)}
+	}
+
+	events := []*QueueEvent{
+		{Seq: 1, Class: "201", StudentID: "a", Question: question("a", StatusWaiting, 0)},
+		{Seq: 2, Class: "201", StudentID: "b", Question: question("b", StatusWaiting, 1)},
+		{Seq: 3, Class: "330", StudentID: "x", Question: &Question{ID: "x", Class: "330"}},
+		{Seq: 4, Class: "201", StudentID: "c", Question: question("c", StatusWaiting, 2)},
+		{Seq: 5, Class: "201", StudentID: "a", Question: question("a", StatusClaimed, 0)},
+		{Seq: 6, Class: "201", StudentID: "b", Question: question("b", StatusWithdrawn, 1)},
+		// d moved to the front
+		{Seq: 8, Class: "201", StudentID: "d", Question: question("d", StatusWaiting, 3), Score: question("a", "", -1).QueueScore()},
+		{Seq: 7, Class: "201", StudentID: "d", Question: question("d", StatusWaiting, 3)},
+	}
+
+	cases := []struct {
+		name    string
+		until   int64
+		queue   string
+		helping string
+	}{
+		{"Before anything", 0, "", ""},
+		{"Three in line", 4, "abc", ""},
+		{"First claimed", 5, "bc", "a"},
+		{"Second withdrawn, out of order sequence", 7, "cd", "a"},
+		{"Moved to the front", 8, "dc", "a"},
+	}
+
+	for _, c := range cases {
+		var replayed []*QueueEvent
+		for _, e := range events {
+			if e.Seq <= c.until {
+				replayed = append(replayed, e)
+			}
+		}
+
+		queue := ReplayQueue("201", replayed, start)
+		got, helping := "", ""
+		for _, q := range queue.Queue {
+			got += q.ID
+		}
+		for _, q := range queue.Helping {
+			helping += q.ID
+		}
+		if got != c.queue || helping != c.helping {
+			t.Errorf("%v: expected %q waiting and %q helped, got %q and %q", c.name, c.queue, c.helping, got, helping)
+		}
+	}
+}
+
+func TestReplayQueue_Policy(t *testing.T) {
+	start := time.Now()
+	events := []*QueueEvent{
+		{Seq: 1, Class: "201", StudentID: "a", Question: &Question{ID: "a", Class: "201", PriorQuestions: 1, CreatedAt: start}},
+		{Seq: 2, Class: "201", StudentID: "b", Question: &Question{ID: "b", Class: "201", CreatedAt: start.Add(time.Minute)}},
+		{Seq: 3, Class: "201", Policy: &Policy{Class: "201", Name: PolicyFirstOfSession}},
+		{Seq: 4, Class: "330", Policy: NewPolicy("330")},
+	}
+
+	cases := []struct {
+		name     string
+		until    int64
+		expected string
+	}{
+		{"Before the policy", 2, "ab"},
+		{"First askers ahead", 4, "ba"},
+	}
+
+	for _, c := range cases {
+		var replayed []*QueueEvent
+		for _, e := range events {
+			if e.Seq <= c.until {
+				replayed = append(replayed, e)
+			}
+		}
+
+		got := ""
+		for _, q := range ReplayQueue("201", replayed, start).Queue {
+			got += q.ID
+		}
+		if got != c.expected {
+			t.Errorf("%v: expected %q waiting, got %q", c.name, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Statuses of a queue; students can only join an open queue.
+const (
+	QueueOpen   = "open"
+	QueuePaused = "paused"
+	QueueClosed = "closed"
+)
+
+var (
+	ErrQueueClosed       = errors.New("the queue is closed")
+	ErrQueuePaused       = errors.New("the queue is paused")
+	ErrQueueFull         = errors.New("the queue is full")
+	ErrLastCallPassed    = errors.New("the last call for questions has passed")
+	ErrInvalidQueueState = errors.New("invalid queue state")
+)
+
+// QueueState is whether and how students can join the queue of a class.
+// A class that never had a state set is open without limits.
+type QueueState struct {
+	Class  string `json:"class"`
+	Status string `json:"status"`
+	// shown to students while the queue is not open, e.g. "back at 3pm"
+	Message string `json:"message,omitempty"`
+	// most students that can wait in line at once; 0 means no limit
+	MaxLength int `json:"max_length"`
+	// no new questions are taken after the last call, if set
+	LastCall  time.Time `json:"last_call"`
+	UpdatedBy string    `json:"updated_by,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewQueueState returns the state of a class that never had one set.
+func NewQueueState(class string) *QueueState {
+	return &QueueState{Class: class, Status: QueueOpen}
+}
+
+// Validate checks the fields of a queue state a TA/teacher can set.
+func (s *QueueState) Validate() error {
+	switch s.Status {
+	case QueueOpen, QueuePaused, QueueClosed:
+	default:
+		return ErrInvalidQueueState
+	}
+	if s.MaxLength < 0 {
+		return ErrInvalidQueueState
+	}
+	return nil
+}
+
+// Admits returns why a new question cannot join the queue at a given time, if it cannot;
+// the length of the line is checked apart, see `MaxLength`.
+func (s *QueueState) Admits(at time.Time) error {
+	switch s.Status {
+	case QueueClosed:
+		return ErrQueueClosed
+	case QueuePaused:
+		return ErrQueuePaused
+	}
+	if !s.LastCall.IsZero() && at.After(s.LastCall) {
+		return ErrLastCallPassed
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueState_Admits(t *testing.T) {
+
+	now := time.Now()
+
+	cases := []struct {
+		name     string
+		state    *QueueState
+		expected error
+	}{
+		{"Never set", NewQueueState("class"), nil},
+		{"Open", &QueueState{Status: QueueOpen}, nil},
+		{"Paused", &QueueState{Status: QueuePaused, Message: "back at 3pm"}, ErrQueuePaused},
+		{"Closed", &QueueState{Status: QueueClosed}, ErrQueueClosed},
+		{"Before the last call", &QueueState{Status: QueueOpen, LastCall: now.Add(time.Minute)}, nil},
+		{"After the last call", &QueueState{Status: QueueOpen, LastCall: now.Add(-time.Minute)}, ErrLastCallPassed},
+	}
+
+	for _, c := range cases {
+		if err := c.state.Admits(now); err != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestQueueState_Validate(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		state    *QueueState
+		expected error
+	}{
+		{"Paused with a message", &QueueState{Status: QueuePaused, Message: "break"}, nil},
+		{"Limited", &QueueState{Status: QueueOpen, MaxLength: 10}, nil},
+		{"Unknown status", &QueueState{Status: "ajar"}, ErrInvalidQueueState},
+		{"Negative length", &QueueState{Status: QueueOpen, MaxLength: -1}, ErrInvalidQueueState},
+	}
+
+	for _, c := range cases {
+		if err := c.state.Validate(); err != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Roles of TA/teachers, from the least to the most trusted; see `HasRole`.
+const (
+	// helps students in the queues of the classes they are staff of
+	RoleTA = "ta"
+	// also manages the settings and staff of the classes they are staff of
+	RoleInstructor = "instructor"
+	// manages every class and TA/teacher
+	RoleAdmin = "admin"
+)
+
+var (
+	ErrInvalidRole  = errors.New("invalid role")
+	ErrInvalidStaff = errors.New("staff of a class are TAs or instructors; only instructors and admins can be instructors")
+)
+
+// roleRanks ranks the roles by trust.
+var roleRanks = map[string]int{
+	RoleTA:         1,
+	RoleInstructor: 2,
+	RoleAdmin:      3,
+}
+
+// Staff is a TA/teacher on the staff of a class, as a TA or an instructor.
+type Staff struct {
+	Class     string    `json:"class"`
+	TeacherID string    `json:"teacher_id"`
+	Role      string    `json:"role"`
+	AddedBy   string    `json:"added_by"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// RoleUpdate is the role an admin gives a TA/teacher.
+type RoleUpdate struct {
+	Role string `json:"role"`
+}
+
+// ValidateRole reports whether a role is one of the roles.
+func ValidateRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole reports whether `role` is as trusted as `least` or more; no role is trusted with nothing.
+func HasRole(role, least string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[least]
+}
+
+// AccountRole returns the role of the account of a TA/teacher; accounts made before roles are TAs.
+func (t *Teacher) AccountRole() string {
+	if !ValidateRole(t.Role) {
+		return RoleTA
+	}
+	return t.Role
+}
+
+// Validate checks that a TA/teacher can join the staff of a class with the role given, by the role of their account.
+func (s *Staff) Validate(t *Teacher) error {
+	switch s.Role {
+	case RoleTA:
+	case RoleInstructor:
+		if !HasRole(t.AccountRole(), RoleInstructor) {
+			return ErrInvalidStaff
+		}
+	default:
+		return ErrInvalidStaff
+	}
+	return nil
+}
+
+// ClassRole returns the role of a TA/teacher in a class given their membership of its staff, nil if none:
+// admins are admins of every class, and the others have the role they were given on the staff, if any.
+func ClassRole(t *Teacher, staff *Staff) string {
+	if t.AccountRole() == RoleAdmin {
+		return RoleAdmin
+	}
+	if staff == nil || staff.TeacherID != t.ID.Hex() {
+		return ""
+	}
+	return staff.Role
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"testing"
+)
+
+func TestHasRole(t *testing.T) {
+	cases := []struct {
+		role, least string
+		expected    bool
+	}{
+		{RoleAdmin, RoleInstructor, true},
+		{RoleInstructor, RoleInstructor, true},
+		{RoleTA, RoleInstructor, false},
+		{RoleTA, RoleTA, true},
+		{"", RoleTA, false},
+		{"owner", RoleTA, false},
+	}
+
+	for _, c := range cases {
+		if HasRole(c.role, c.least) != c.expected {
+			t.Errorf("%q at least %q: expected %v", c.role, c.least, c.expected)
+		}
+	}
+}
+
+func TestClassRole(t *testing.T) {
+	admin := &Teacher{ID: primitive.NewObjectID(), Role: RoleAdmin}
+	instructor := &Teacher{ID: primitive.NewObjectID(), Role: RoleInstructor}
+	legacy := &Teacher{ID: primitive.NewObjectID()}
+
+	cases := []struct {
+		name     string
+		teacher  *Teacher
+		staff    *Staff
+		expected string
+	}{
+		{"Admin of any class", admin, nil, RoleAdmin},
+		{"Not on the staff", instructor, nil, ""},
+		{"On the staff", instructor, &Staff{TeacherID: instructor.ID.Hex(), Role: RoleTA}, RoleTA},
+		{"Staff of someone else", legacy, &Staff{TeacherID: instructor.ID.Hex(), Role: RoleInstructor}, ""},
+	}
+
+	for _, c := range cases {
+		if role := ClassRole(c.teacher, c.staff); role != c.expected {
+			t.Errorf("%v: expected %q, got %q", c.name, c.expected, role)
+		}
+	}
+}
+
+func TestStaff_Validate(t *testing.T) {
+	cases := []struct {
+		role    string
+		account string
+		valid   bool
+	}{
+		{RoleTA, "", true},
+		{RoleTA, RoleInstructor, true},
+		{RoleInstructor, RoleInstructor, true},
+		{RoleInstructor, RoleAdmin, true},
+		{RoleInstructor, RoleTA, false},
+		{RoleAdmin, RoleAdmin, false},
+		{"", RoleAdmin, false},
+	}
+
+	for _, c := range cases {
+		err := (&Staff{Role: c.role}).Validate(&Teacher{Role: c.account})
+		if (err == nil) != c.valid {
+			t.Errorf("%q with a %q account: expected valid to be %v, got %v", c.role, c.account, c.valid, err)
+		}
+	}
+}
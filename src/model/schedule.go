@@ -0,0 +1,171 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Kinds of a slot in the schedule of a class.
+const (
+	SlotLab         = "lab"
+	SlotOfficeHours = "office-hours"
+)
+
+// slotClock is the layout of the start and end of a slot, in the time zone of its schedule.
+const slotClock = "15:04"
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule is the recurring weekly lab and office-hour slots of a class,
+// in the IANA time zone of the class, e.g. "America/Los_Angeles".
+// The queue of the class opens at the start of every slot and closes at its end.
+type Schedule struct {
+	Class    string  `json:"class" bson:"class"`
+	TimeZone string  `json:"time_zone" bson:"timezone"`
+	Slots    []*Slot `json:"slots" bson:"slots"`
+}
+
+// Slot is a weekly lab or office-hour meeting of a class.
+type Slot struct {
+	Kind     string       `json:"kind" bson:"kind"`
+	Day      time.Weekday `json:"day" bson:"day"`
+	Start    string       `json:"start" bson:"start"`
+	End      string       `json:"end" bson:"end"`
+	Location string       `json:"location,omitempty" bson:"location"`
+}
+
+// Meeting is one occurrence of a slot of a schedule.
+type Meeting struct {
+	Class    string    `json:"class"`
+	Kind     string    `json:"kind"`
+	Location string    `json:"location,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// Validate checks the time zone of a schedule, that every slot ends after it starts on the same day
+// and that no two slots overlap; a slot can start as another ends.
+func (s *Schedule) Validate() error {
+	if _, err := time.LoadLocation(s.TimeZone); err != nil || len(s.TimeZone) == 0 {
+		return ErrInvalidSchedule
+	}
+	for _, slot := range s.Slots {
+		if slot.Kind != SlotLab && slot.Kind != SlotOfficeHours {
+			return ErrInvalidSchedule
+		}
+		if slot.Day < time.Sunday || slot.Day > time.Saturday {
+			return ErrInvalidSchedule
+		}
+		start, err := time.Parse(slotClock, slot.Start)
+		if err != nil {
+			return ErrInvalidSchedule
+		}
+		end, err := time.Parse(slotClock, slot.End)
+		if err != nil || !end.After(start) {
+			return ErrInvalidSchedule
+		}
+	}
+	for i, slot := range s.Slots {
+		for _, other := range s.Slots[i+1:] {
+			if slot.Day == other.Day && slot.overlaps(other) {
+				return ErrInvalidSchedule
+			}
+		}
+	}
+	return nil
+}
+
+// Meetings returns the meetings of a schedule that have not ended at a given time,
+// soonest first, up to `n` of them; a meeting in progress comes first.
+// The schedule must be valid.
+func (s *Schedule) Meetings(at time.Time, n int) []*Meeting {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil
+	}
+
+	local := at.In(loc)
+	var meetings []*Meeting
+	// every slot happens within a week; a meeting in progress started today at the earliest
+	for days := 0; days <= 7; days++ {
+		date := local.AddDate(0, 0, days)
+		for _, slot := range s.Slots {
+			if slot.Day != date.Weekday() {
+				continue
+			}
+			start := slotTime(date, slot.Start, loc)
+			end := slotTime(date, slot.End, loc)
+			if !end.After(at) {
+				continue
+			}
+			meetings = append(meetings, &Meeting{s.Class, slot.Kind, slot.Location, start, end})
+		}
+	}
+
+	sort.Slice(meetings, func(i, j int) bool { return meetings[i].Start.Before(meetings[j].Start) })
+	if len(meetings) > n {
+		meetings = meetings[:n]
+	}
+	return meetings
+}
+
+// overlaps reports whether two valid slots on the same day share some time.
+func (slot *Slot) overlaps(other *Slot) bool {
+	start, _ := time.Parse(slotClock, slot.Start)
+	end, _ := time.Parse(slotClock, slot.End)
+	otherStart, _ := time.Parse(slotClock, other.Start)
+	otherEnd, _ := time.Parse(slotClock, other.End)
+	return start.Before(otherEnd) && otherStart.Before(end)
+}
+
+// InProgress returns the meetings of a schedule in progress at a given time.
+// The schedule must be valid.
+func (s *Schedule) InProgress(at time.Time) []*Meeting {
+	var meetings []*Meeting
+	// meetings in progress come first, one per slot at most
+	for _, m := range s.Meetings(at, len(s.Slots)) {
+		if !m.Start.After(at) {
+			meetings = append(meetings, m)
+		}
+	}
+	return meetings
+}
+
+// LastChange returns the meeting whose start or end was the last to open or close the queue
+// of a schedule up to a given time, and whether it opened it, or nil for a schedule without slots;
+// a meeting that starts as another ends opens the queue. The schedule must be valid.
+func (s *Schedule) LastChange(at time.Time) (*Meeting, bool) {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return nil, false
+	}
+
+	local := at.In(loc)
+	var last *Meeting
+	var lastAt time.Time
+	var opened bool
+	// every slot happened within the last week
+	for days := -7; days <= 0; days++ {
+		date := local.AddDate(0, 0, days)
+		for _, slot := range s.Slots {
+			if slot.Day != date.Weekday() {
+				continue
+			}
+			m := &Meeting{s.Class, slot.Kind, slot.Location, slotTime(date, slot.Start, loc), slotTime(date, slot.End, loc)}
+			if !m.End.After(at) && (last == nil || m.End.After(lastAt)) {
+				last, lastAt, opened = m, m.End, false
+			}
+			if !m.Start.After(at) && (last == nil || !m.Start.Before(lastAt)) {
+				last, lastAt, opened = m, m.Start, true
+			}
+		}
+	}
+	return last, opened
+}
+
+// slotTime returns the time of day given in the slot clock layout on a date in a time zone.
+func slotTime(date time.Time, clock string, loc *time.Location) time.Time {
+	t, _ := time.Parse(slotClock, clock)
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+}
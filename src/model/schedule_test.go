@@ -0,0 +1,155 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Validate(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		schedule *Schedule
+		expected error
+	}{
+		{
+			name: "Valid",
+			schedule: &Schedule{TimeZone: "America/Los_Angeles", Slots: []*Slot{
+				{Kind: SlotLab, Day: time.Monday, Start: "13:30", End: "15:20"},
+			}},
+		},
+		{
+			name:     "Unknown time zone",
+			schedule: &Schedule{TimeZone: "Mars/Olympus_Mons"},
+			expected: ErrInvalidSchedule,
+		},
+		{
+			name: "Ends before it starts",
+			schedule: &Schedule{TimeZone: "UTC", Slots: []*Slot{
+				{Kind: SlotOfficeHours, Day: time.Friday, Start: "15:00", End: "14:00"},
+			}},
+			expected: ErrInvalidSchedule,
+		},
+		{
+			name: "Overlapping slots",
+			schedule: &Schedule{TimeZone: "UTC", Slots: []*Slot{
+				{Kind: SlotLab, Day: time.Monday, Start: "13:30", End: "15:20"},
+				{Kind: SlotOfficeHours, Day: time.Monday, Start: "9:00", End: "14:00"},
+			}},
+			expected: ErrInvalidSchedule,
+		},
+		{
+			name: "One slot starts as another ends",
+			schedule: &Schedule{TimeZone: "UTC", Slots: []*Slot{
+				{Kind: SlotLab, Day: time.Monday, Start: "13:30", End: "15:20"},
+				{Kind: SlotOfficeHours, Day: time.Monday, Start: "15:20", End: "16:00"},
+				{Kind: SlotOfficeHours, Day: time.Tuesday, Start: "14:00", End: "15:00"},
+			}},
+		},
+		{
+			name: "Unknown kind",
+			schedule: &Schedule{TimeZone: "UTC", Slots: []*Slot{
+				{Kind: "lecture", Day: time.Friday, Start: "14:00", End: "15:00"},
+			}},
+			expected: ErrInvalidSchedule,
+		},
+	}
+
+	for _, c := range cases {
+		if err := c.schedule.Validate(); err != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestSchedule_Meetings(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	schedule := &Schedule{Class: "343", TimeZone: "America/Los_Angeles", Slots: []*Slot{
+		{Kind: SlotLab, Day: time.Monday, Start: "13:30", End: "15:20", Location: "MGH 430"},
+		{Kind: SlotOfficeHours, Day: time.Wednesday, Start: "10:00", End: "11:00"},
+	}}
+
+	// during the Monday lab, 2019-06-03 14:00 in Seattle
+	at := time.Date(2019, time.June, 3, 14, 0, 0, 0, loc)
+	meetings := schedule.Meetings(at, 3)
+
+	expected := []time.Time{
+		time.Date(2019, time.June, 3, 13, 30, 0, 0, loc),
+		time.Date(2019, time.June, 5, 10, 0, 0, 0, loc),
+		time.Date(2019, time.June, 10, 13, 30, 0, 0, loc),
+	}
+	if len(meetings) != len(expected) {
+		t.Fatalf("expected %v meetings, got %v", len(expected), len(meetings))
+	}
+	for i, m := range meetings {
+		if !m.Start.Equal(expected[i]) {
+			t.Errorf("expected meeting %v to start at %v, got %v", i, expected[i], m.Start)
+		}
+	}
+	if meetings[0].Kind != SlotLab || meetings[0].Location != "MGH 430" {
+		t.Errorf("expected the lab in progress first, got %v in %v", meetings[0].Kind, meetings[0].Location)
+	}
+}
+
+func TestSchedule_LastChange(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	schedule := &Schedule{Class: "343", TimeZone: "America/Los_Angeles", Slots: []*Slot{
+		{Kind: SlotLab, Day: time.Monday, Start: "13:30", End: "15:20"},
+		{Kind: SlotOfficeHours, Day: time.Monday, Start: "15:20", End: "16:00"},
+		{Kind: SlotOfficeHours, Day: time.Wednesday, Start: "10:00", End: "11:00"},
+	}}
+
+	cases := []struct {
+		name   string
+		at     time.Time
+		start  time.Time
+		opened bool
+	}{
+		{"During the lab", time.Date(2019, time.June, 3, 14, 0, 0, 0, loc), time.Date(2019, time.June, 3, 13, 30, 0, 0, loc), true},
+		{"As the lab ends and office hours start", time.Date(2019, time.June, 3, 15, 20, 0, 0, loc), time.Date(2019, time.June, 3, 15, 20, 0, 0, loc), true},
+		{"After office hours", time.Date(2019, time.June, 4, 9, 0, 0, 0, loc), time.Date(2019, time.June, 3, 15, 20, 0, 0, loc), false},
+		{"Before the lab, a week after", time.Date(2019, time.June, 10, 8, 0, 0, 0, loc), time.Date(2019, time.June, 5, 10, 0, 0, 0, loc), false},
+	}
+
+	for _, c := range cases {
+		m, opened := schedule.LastChange(c.at)
+		if m == nil {
+			t.Errorf("%v: expected a meeting, got none", c.name)
+			continue
+		}
+		if !m.Start.Equal(c.start) || opened != c.opened {
+			t.Errorf("%v: expected the meeting at %v to have opened %v, got %v and %v", c.name, c.start, c.opened, m.Start, opened)
+		}
+	}
+
+	if m, _ := (&Schedule{TimeZone: "UTC"}).LastChange(time.Now()); m != nil {
+		t.Errorf("expected no meeting without slots, got %v", m)
+	}
+}
+
+func TestSchedule_InProgress(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone database is not available: %v", err)
+	}
+
+	schedule := &Schedule{Class: "343", TimeZone: "America/Los_Angeles", Slots: []*Slot{
+		{Kind: SlotLab, Day: time.Monday, Start: "13:30", End: "15:20"},
+		{Kind: SlotOfficeHours, Day: time.Monday, Start: "15:20", End: "16:00"},
+	}}
+
+	if meetings := schedule.InProgress(time.Date(2019, time.June, 3, 15, 20, 0, 0, loc)); len(meetings) != 1 || meetings[0].Kind != SlotOfficeHours {
+		t.Errorf("expected only the office hours in progress as the lab ends, got %v", meetings)
+	}
+	if meetings := schedule.InProgress(time.Date(2019, time.June, 3, 16, 0, 0, 0, loc)); len(meetings) != 0 {
+		t.Errorf("expected no meeting in progress after office hours, got %v", meetings)
+	}
+}
@@ -14,6 +14,9 @@ type Teacher struct {
 	PasswordHash string             `json:"password_hash,omitempty" bson:"passwordhash"`
 	FirstName    string             `json:"first_name"              bson:"firstname"`
 	LastName     string             `json:"last_name"               bson:"lastname"`
+	Role         string             `json:"role"                    bson:"role"`
+	// whether they proved they get the mail sent to `Email`, see `PurposeVerifyEmail`
+	EmailVerified bool `json:"email_verified" bson:"emailverified"`
 }
 
 type TeacherUpdate struct {
@@ -35,6 +38,10 @@ type NewTeacher struct {
 	PasswordConf string `json:"password_conf"`
 	FirstName    string `json:"first_name"`
 	LastName     string `json:"last_name"`
+	// token of the invite to register with, see `Invite`
+	Invite string `json:"invite"`
+	// given by the server, never by the new TA/teacher
+	Role string `json:"-"`
 }
 
 type TeacherLogin struct {
@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// DefaultHelpDuration is the seconds a TA/teacher is expected to help a student
+// when a class has no recently resolved questions to learn from.
+const DefaultHelpDuration = 5 * 60
+
+// RecentHelpWindow is how far back resolved questions count towards the wait statistics of a class.
+const RecentHelpWindow = 2 * time.Hour
+
+// WaitStats is what the estimated wait of the students in line of a class is computed from.
+type WaitStats struct {
+	// average seconds a TA/teacher recently took to help a student
+	AverageHelp float64 `json:"average_help"`
+	// number of TA/teachers recently helping students
+	TAs int `json:"tas"`
+}
+
+// NewWaitStats computes the wait statistics of a class from its recently resolved questions,
+// the questions TA/teachers are helping with right now and the TA/teachers who declared they are on duty.
+// Every TA/teacher on duty or helping someone counts; when nobody declared a duty,
+// the TA/teachers who resolved recent questions count as on duty too.
+func NewWaitStats(resolved, helping []*Question, duties []*Duty) *WaitStats {
+	stats := &WaitStats{AverageHelp: DefaultHelpDuration}
+
+	tas := make(map[string]bool)
+	for _, d := range duties {
+		tas[d.TeacherID] = true
+	}
+	total, count := 0.0, 0
+	for _, q := range resolved {
+		if len(q.ResolvedBy) != 0 && len(duties) == 0 {
+			tas[q.ResolvedBy] = true
+		}
+		if q.HelpDuration > 0 {
+			total += q.HelpDuration
+			count++
+		}
+	}
+	for _, q := range helping {
+		if len(q.ClaimedBy) != 0 {
+			tas[q.ClaimedBy] = true
+		}
+	}
+
+	if count > 0 {
+		stats.AverageHelp = total / float64(count)
+	}
+	stats.TAs = len(tas)
+	return stats
+}
+
+// EstimateWait returns the seconds a student at a 1-based position in line is expected
+// to wait, as if every TA/teacher on duty helped one student after the other;
+// students being helped do not wait.
+func (s *WaitStats) EstimateWait(position int) float64 {
+	if position < 1 {
+		return 0
+	}
+	tas := s.TAs
+	if tas < 1 {
+		tas = 1
+	}
+	return float64(position) * s.AverageHelp / float64(tas)
+}
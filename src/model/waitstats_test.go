@@ -0,0 +1,81 @@
+package model
+
+import "testing"
+
+func TestNewWaitStats(t *testing.T) {
+
+	cases := []struct {
+		name        string
+		resolved    []*Question
+		helping     []*Question
+		duties      []*Duty
+		averageHelp float64
+		tas         int
+	}{
+		{
+			name:        "No history",
+			averageHelp: DefaultHelpDuration,
+		},
+		{
+			name: "Average of resolved questions",
+			resolved: []*Question{
+				{ResolvedBy: "ta", HelpDuration: 60},
+				{ResolvedBy: "ta", HelpDuration: 180},
+			},
+			averageHelp: 120,
+			tas:         1,
+		},
+		{
+			name: "TAs resolving or helping count once",
+			resolved: []*Question{
+				{ResolvedBy: "ta", HelpDuration: 60},
+				{ResolvedBy: "other", HelpDuration: 0},
+			},
+			helping: []*Question{
+				{Status: StatusClaimed, ClaimedBy: "other"},
+				{Status: StatusInProgress, ClaimedBy: "third"},
+			},
+			averageHelp: 60,
+			tas:         3,
+		},
+		{
+			name: "TAs on duty or helping count, not the ones who went off duty",
+			resolved: []*Question{
+				{ResolvedBy: "gone", HelpDuration: 60},
+			},
+			helping: []*Question{
+				{Status: StatusClaimed, ClaimedBy: "undeclared"},
+			},
+			duties:      []*Duty{{TeacherID: "ta"}, {TeacherID: "other"}},
+			averageHelp: 60,
+			tas:         3,
+		},
+	}
+
+	for _, c := range cases {
+		stats := NewWaitStats(c.resolved, c.helping, c.duties)
+		if stats.AverageHelp != c.averageHelp {
+			t.Errorf("%v: expected an average help of %v, got %v", c.name, c.averageHelp, stats.AverageHelp)
+		}
+		if stats.TAs != c.tas {
+			t.Errorf("%v: expected %v TAs, got %v", c.name, c.tas, stats.TAs)
+		}
+	}
+}
+
+func TestQuestionQueue_GetStudentPositions_EstimatedWait(t *testing.T) {
+
+	queue := &QuestionQueue{
+		Class:   "class",
+		Queue:   []*Question{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		Helping: []*Question{{ID: "d", Status: StatusInProgress, ClaimedBy: "ta"}},
+		Wait:    &WaitStats{AverageHelp: 60, TAs: 2},
+	}
+
+	expected := map[string]float64{"a": 30, "b": 60, "c": 90, "d": 0}
+	for id, position := range queue.GetStudentPositions() {
+		if position.EstimatedWait != expected[id] {
+			t.Errorf("expected %v to wait %v seconds, got %v", id, expected[id], position.EstimatedWait)
+		}
+	}
+}
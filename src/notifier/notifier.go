@@ -26,9 +26,29 @@ type Notifier struct {
 	queue amqp.Queue
 }
 
+// message types, one for every change of a question in the queue
 const (
-	QuestionNew    = "question-new"
-	QuestionDelete = "question-delete"
+	QuestionNew        = "question-new"
+	QuestionUpdate     = "question-update"
+	QuestionClaimed    = "question-claimed"
+	QuestionGrouped    = "question-grouped"
+	QuestionRequeue    = "question-requeue"
+	QuestionMoved      = "question-moved"
+	QuestionHeld       = "question-held"
+	QuestionSkipped    = "question-skipped"
+	QuestionInProgress = "question-in-progress"
+	QuestionResolved   = "question-resolved"
+	QuestionNoShow     = "question-no-show"
+	QuestionWithdrawn  = "question-withdrawn"
+	QuestionArchived   = "question-archived"
+)
+
+// message types of a change to a queue itself
+const (
+	// whether and how students can join a queue
+	QueueState = "queue-state"
+	// how the students waiting in line are ordered
+	QueuePolicy = "queue-policy"
 )
 
 type Message struct {
@@ -38,6 +58,8 @@ type Message struct {
 	Content interface{} `json:"content"`
 	// creator of the message
 	UserID  interface{} `json:"userID"`
+	// class code of the queue the message belongs to
+	Class   string      `json:"class"`
 }
 
 // create and return a new socket store
@@ -0,0 +1,87 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// "HELLO WORLD" at 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if ec := reedSolomonRemainder(data, reedSolomonDivisor(len(expected))); !bytes.Equal(ec, expected) {
+		t.Errorf("expected %v, got %v", expected, ec)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	if bits := fmt.Sprintf("%015b", formatBits(0)); bits != "101010000010010" {
+		t.Errorf("expected format bits 101010000010010, got %v", bits)
+	}
+	if bits := fmt.Sprintf("%018b", versionBits(7)); bits != "000111110010010100" {
+		t.Errorf("expected version bits 000111110010010100, got %v", bits)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		text    string
+		version int
+	}{
+		{"", 1},
+		{strings.Repeat("a", 14), 1},
+		{strings.Repeat("a", 15), 2},
+		{"https://queue.example.com/join?class=343&code=K7PQ2M", 4},
+		{strings.Repeat("a", 213), 10},
+	}
+
+	for _, c := range cases {
+		code, err := Encode(c.text)
+		if err != nil {
+			t.Fatalf("%v characters: unexpected error %v", len(c.text), err)
+		}
+		if code.Version != c.version || code.Size != 4*c.version+17 {
+			t.Errorf("%v characters: expected version %v, got %v of size %v", len(c.text), c.version, code.Version, code.Size)
+		}
+
+		// timing patterns alternate between the finder patterns
+		for i := 8; i < code.Size-8; i++ {
+			if code.Modules[6][i] != (i%2 == 0) || code.Modules[i][6] != (i%2 == 0) {
+				t.Errorf("%v characters: broken timing pattern at %v", len(c.text), i)
+			}
+		}
+
+		// the dark module
+		if !code.Modules[code.Size-8][8] {
+			t.Errorf("%v characters: expected the dark module to be dark", len(c.text))
+		}
+	}
+
+	if _, err := Encode(strings.Repeat("a", 214)); err != ErrTooLong {
+		t.Errorf("expected %v, got %v", ErrTooLong, err)
+	}
+}
+
+func TestCode_WritePNG(t *testing.T) {
+	code, err := Encode("343")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := code.WritePNG(&buf, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if side := (code.Size + 2*quietZone) * 4; img.Bounds().Dx() != side || img.Bounds().Dy() != side {
+		t.Errorf("expected a %vx%v image, got %v", side, side, img.Bounds())
+	}
+}
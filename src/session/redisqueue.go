@@ -0,0 +1,921 @@
+package session
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"questionqueue/src/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Every class has its own question queue in redis, made of three keys:
+// - "queue:<class>" is a sorted set of student IDs, scored by their place in line;
+// - "queue:<class>:questions" is a hash of student ID to the JSON encoded `model.Question`;
+// - "queue:<class>:owners" is a hash of student ID to the hash of the token the student owns the question with.
+// Alongside, "queue:<class>:wait" holds the JSON encoded `model.WaitStats` of the class
+// and "queue:<class>:state", "queue:<class>:policy" and "queue:<class>:dispatch" its JSON encoded
+// `model.QueueState`, `model.Policy` and `model.Dispatch`; "queue:<class>:duty" is a hash of
+// teacher ID to the JSON encoded `model.Duty` of the TA/teachers on duty.
+// All mutations of a queue run as lua scripts or watched transactions so that they are atomic on the redis server.
+// A change recorded in the event log of a queue gets the next number of the counter "events:seq" and is appended
+// to "queue:<class>:events", a list of "<seq> <JSON encoded model.QueueEvent>", in the same atomic step;
+// it stays there until `FlushEvents` hands it over to the event log.
+
+// ErrQuestionNotFound is returned when a student is not in the queue of a class.
+var ErrQuestionNotFound = errors.New("question not found")
+
+// ErrQuestionExists is returned when a student is already in the queue of a class.
+var ErrQuestionExists = errors.New("question already exists in the queue")
+
+// ErrNotOwner is returned when a token does not prove that a student owns their question.
+var ErrNotOwner = errors.New("not the owner of the question")
+
+// enqueueScript adds a question at the place given by its score unless the
+// student is already in line, in which case the existing question is returned.
+// New questions are rejected with the error ARGV[4], if given, or with the error ARGV[7]
+// once ARGV[5] students, if more than 0, are waiting in line with the status ARGV[6].
+// The hash of the token of the student, ARGV[8], is kept along with the question, if given,
+// and the event ARGV[9], if given, is numbered and appended to the events of the queue.
+var enqueueScript = redis.NewScript(`
+local existing = redis.call('HGET', KEYS[2], ARGV[1])
+if existing then
+	return existing
+end
+if ARGV[4] ~= '' then
+	return redis.error_reply(ARGV[4])
+end
+local max = tonumber(ARGV[5])
+if max > 0 then
+	local waiting = 0
+	for _, question in ipairs(redis.call('HVALS', KEYS[2])) do
+		local status = cjson.decode(question).status
+		if status == nil or status == '' or status == ARGV[6] then
+			waiting = waiting + 1
+		end
+	end
+	if waiting >= max then
+		return redis.error_reply(ARGV[7])
+	end
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[3])
+if ARGV[8] ~= '' then
+	redis.call('HSET', KEYS[3], ARGV[1], ARGV[8])
+end
+if ARGV[9] ~= '' then
+	local seq = redis.call('INCR', KEYS[4])
+	redis.call('RPUSH', KEYS[5], seq .. ' ' .. ARGV[9])
+end
+return false
+`)
+
+// logScript numbers the event ARGV[1] with the next sequence number and appends it to the events of a queue;
+// it runs within the transaction of the change the event records.
+var logScript = redis.NewScript(`
+local seq = redis.call('INCR', KEYS[1])
+redis.call('RPUSH', KEYS[2], seq .. ' ' .. ARGV[1])
+return seq
+`)
+
+// seedScript raises the event counter to ARGV[1] if it is lower.
+var seedScript = redis.NewScript(`
+local seq = tonumber(redis.call('GET', KEYS[1]) or '0')
+if seq < tonumber(ARGV[1]) then
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return false
+`)
+
+// readScript returns all questions in the order of the line.
+var readScript = redis.NewScript(`
+local ids = redis.call('ZRANGE', KEYS[1], 0, -1)
+if #ids == 0 then
+	return {}
+end
+return redis.call('HMGET', KEYS[2], unpack(ids))
+`)
+
+// Enqueue atomically puts a question at the end of the queue of its class.
+// A student only has one question per queue; if the student is already in line,
+// the existing question is returned along with ErrQuestionExists.
+func (rs *RedisStore) Enqueue(question *model.Question) (*model.Question, error) {
+	return rs.EnqueueIfOpen(question, nil, "", nil)
+}
+
+// EnqueueIfOpen is Enqueue for a queue in the given state: a student who is not in line yet
+// is rejected with the error of `model.QueueState.Admits`, or with model.ErrQueueFull once
+// `model.QueueState.MaxLength` students are waiting. A nil state admits every question.
+// The student can prove they own the question with `token` while it is in the queue, if given;
+// see `VerifyOwner`. The question joining the line is recorded as `event`, if given; see `stampEvent`.
+func (rs *RedisStore) EnqueueIfOpen(question *model.Question, state *model.QueueState, token string, event *model.QueueEvent) (*model.Question, error) {
+	return rs.enqueue(question, state, HashOwnerToken(token), event)
+}
+
+// Restore puts a question back at its place in the queue of its class whatever the state of the queue,
+// along with the hash of the token of its owner, `model.Question.OwnerHash`, and records it as `event`, if given;
+// if the student is already in line, the existing question is returned along with ErrQuestionExists.
+func (rs *RedisStore) Restore(question *model.Question, event *model.QueueEvent) (*model.Question, error) {
+	return rs.enqueue(question, nil, question.OwnerHash, event)
+}
+
+// enqueue is EnqueueIfOpen with the hash of the token, if any, rather than the token.
+func (rs *RedisStore) enqueue(question *model.Question, state *model.QueueState, ownerHash string, event *model.QueueEvent) (*model.Question, error) {
+	j, err := json.Marshal(question)
+	if err != nil {
+		return nil, err
+	}
+	e, err := stampEvent(event, question, question.QueueScore())
+	if err != nil {
+		return nil, err
+	}
+
+	var rejection error
+	maxLength := 0
+	if state != nil {
+		rejection = state.Admits(time.Now())
+		maxLength = state.MaxLength
+	}
+	reason := ""
+	if rejection != nil {
+		reason = rejection.Error()
+	}
+
+	s, err := enqueueScript.Run(rs.Client, append(queueKeys(question.Class), eventKeys(question.Class)...),
+		question.ID, queueScore(question), j, reason, maxLength, model.StatusWaiting, model.ErrQueueFull.Error(), ownerHash, e).String()
+	switch {
+	// the script returns nil once the question is added
+	case err == redis.Nil:
+		return nil, nil
+	case err == nil:
+		existing := &model.Question{}
+		if err := json.Unmarshal([]byte(s), existing); err != nil {
+			return nil, err
+		}
+		return existing, ErrQuestionExists
+	case rejection != nil && err.Error() == reason:
+		return nil, rejection
+	case err.Error() == model.ErrQueueFull.Error():
+		return nil, model.ErrQueueFull
+	default:
+		return nil, err
+	}
+}
+
+// UpdateQuestion applies `update` to the question of a student in the queue of a class
+// without changing their place in line, and returns the updated question.
+// The question is watched while `update` runs; it is retried if anything else
+// changed the queue in the meantime. Errors from `update` abort the update.
+// Questions that are no longer active after the update leave the queue.
+// The change is recorded as `event`, if given, in the same transaction; see `stampEvent`.
+func (rs *RedisStore) UpdateQuestion(class, id string, update func(*model.Question) error, event *model.QueueEvent) (*model.Question, error) {
+	const maxRetries = 10
+
+	for i := 0; i < maxRetries; i++ {
+		q := &model.Question{}
+		err := rs.Client.Watch(func(tx *redis.Tx) error {
+			s, err := tx.HGet(questionsKey(class), id).Result()
+			if err == redis.Nil {
+				return ErrQuestionNotFound
+			} else if err != nil {
+				return err
+			}
+
+			if err := json.Unmarshal([]byte(s), q); err != nil {
+				return err
+			}
+			if err := update(q); err != nil {
+				return err
+			}
+
+			j, err := json.Marshal(q)
+			if err != nil {
+				return err
+			}
+			e, err := stampEvent(event, q, 0)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				if q.IsActive() {
+					pipe.HSet(questionsKey(class), id, j)
+				} else {
+					pipe.ZRem(queueKey(class), id)
+					pipe.HDel(questionsKey(class), id)
+					pipe.HDel(ownersKey(class), id)
+				}
+				logEvent(pipe, class, e)
+				return nil
+			})
+			return err
+		}, queueKeys(class)...)
+
+		if err == redis.TxFailedErr {
+			// the queue changed while updating; try again
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return q, nil
+	}
+
+	return nil, redis.TxFailedErr
+}
+
+// VerifyOwner checks that `token` is the one the student was given along with their question
+// in the queue of a class; it returns ErrNotOwner if it is not, or ErrQuestionNotFound.
+func (rs *RedisStore) VerifyOwner(class, id, token string) error {
+	var exists *redis.BoolCmd
+	var owner *redis.StringCmd
+	if _, err := rs.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		exists = pipe.HExists(questionsKey(class), id)
+		owner = pipe.HGet(ownersKey(class), id)
+		return nil
+	}); err != nil && err != redis.Nil {
+		return err
+	}
+
+	if !exists.Val() {
+		return ErrQuestionNotFound
+	}
+	if len(token) == 0 || len(owner.Val()) == 0 ||
+		subtle.ConstantTimeCompare([]byte(owner.Val()), []byte(HashOwnerToken(token))) != 1 {
+		return ErrNotOwner
+	}
+	return nil
+}
+
+// GetQueue returns a consistent snapshot of the queue of a class, with the students
+// waiting in line, ordered by the policy of the class, apart from the ones being helped;
+// see `model.NewQuestionQueue`. A class nobody has lined up for yet gets an empty queue.
+func (rs *RedisStore) GetQueue(class string) (*model.QuestionQueue, error) {
+	res, err := readScript.Run(rs.Client, queueKeys(class)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var line []*model.Question
+	questions, _ := res.([]interface{})
+	for _, i := range questions {
+		s, ok := i.(string)
+		if !ok {
+			// the hash lost the question, ignore
+			continue
+		}
+		q := &model.Question{}
+		if err := json.Unmarshal([]byte(s), q); err != nil {
+			return nil, err
+		}
+		line = append(line, q)
+	}
+
+	policy, err := rs.GetPolicy(class)
+	if err != nil {
+		return nil, err
+	}
+	queue := model.NewQuestionQueue(class, line, policy, time.Now())
+
+	wait, err := rs.GetWaitStats(class)
+	if err != nil {
+		return nil, err
+	}
+	queue.Wait = wait
+
+	state, err := rs.GetQueueState(class)
+	if err != nil {
+		return nil, err
+	}
+	queue.State = state
+	return queue, nil
+}
+
+// SetPolicy saves how the students waiting in line of a class are ordered
+// and records the change as `event`, if given, in the same transaction.
+func (rs *RedisStore) SetPolicy(policy *model.Policy, event *model.QueueEvent) error {
+	j, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+
+	e := ""
+	if event != nil {
+		event.Class = policy.Class
+		event.Policy = policy
+		if e, err = stampEvent(event, nil, 0); err != nil {
+			return err
+		}
+	}
+	_, err = rs.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(policyKey(policy.Class), j, 0)
+		logEvent(pipe, policy.Class, e)
+		return nil
+	})
+	return err
+}
+
+// GetPolicy returns how the students waiting in line of a class are ordered;
+// a class that never had a policy set is FIFO.
+func (rs *RedisStore) GetPolicy(class string) (*model.Policy, error) {
+	s, err := rs.Client.Get(policyKey(class)).Result()
+	if err == redis.Nil {
+		return model.NewPolicy(class), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	policy := &model.Policy{}
+	if err := json.Unmarshal([]byte(s), policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// SetJoinSettings saves how the join codes of a class are made.
+func (rs *RedisStore) SetJoinSettings(settings *model.JoinSettings) error {
+	j, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return rs.Client.Set(joinKey(settings.Class), j, 0).Err()
+}
+
+// AddJoinSettings saves how the join codes of a class are made unless it already has join settings,
+// and reports whether it did.
+func (rs *RedisStore) AddJoinSettings(settings *model.JoinSettings) (bool, error) {
+	j, err := json.Marshal(settings)
+	if err != nil {
+		return false, err
+	}
+	return rs.Client.SetNX(joinKey(settings.Class), j, 0).Result()
+}
+
+// GetJoinSettings returns how the join codes of a class are made, or nil if it has none yet.
+func (rs *RedisStore) GetJoinSettings(class string) (*model.JoinSettings, error) {
+	s, err := rs.Client.Get(joinKey(class)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	settings := &model.JoinSettings{}
+	if err := json.Unmarshal([]byte(s), settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetDispatch saves how the students waiting in line of a class are handed out to TA/teachers.
+func (rs *RedisStore) SetDispatch(dispatch *model.Dispatch) error {
+	j, err := json.Marshal(dispatch)
+	if err != nil {
+		return err
+	}
+	return rs.Client.Set(dispatchKey(dispatch.Class), j, 0).Err()
+}
+
+// GetDispatch returns how the students waiting in line of a class are handed out to TA/teachers;
+// see `model.NewDispatch` for a class that never had it set.
+func (rs *RedisStore) GetDispatch(class string) (*model.Dispatch, error) {
+	s, err := rs.Client.Get(dispatchKey(class)).Result()
+	if err == redis.Nil {
+		return model.NewDispatch(class), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	dispatch := &model.Dispatch{}
+	if err := json.Unmarshal([]byte(s), dispatch); err != nil {
+		return nil, err
+	}
+	return dispatch, nil
+}
+
+// SetDuty puts a TA/teacher on duty in the queue of a class, replacing the topics they covered.
+func (rs *RedisStore) SetDuty(class string, duty *model.Duty) error {
+	j, err := json.Marshal(duty)
+	if err != nil {
+		return err
+	}
+	return rs.Client.HSet(dutyKey(class), duty.TeacherID, j).Err()
+}
+
+// RemoveDuty takes a TA/teacher off duty in the queue of a class.
+func (rs *RedisStore) RemoveDuty(class, teacherID string) error {
+	return rs.Client.HDel(dutyKey(class), teacherID).Err()
+}
+
+// GetDuty returns the duty of a TA/teacher in the queue of a class, or nil if they are not on duty.
+func (rs *RedisStore) GetDuty(class, teacherID string) (*model.Duty, error) {
+	s, err := rs.Client.HGet(dutyKey(class), teacherID).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	duty := &model.Duty{}
+	if err := json.Unmarshal([]byte(s), duty); err != nil {
+		return nil, err
+	}
+	return duty, nil
+}
+
+// GetDuties returns the TA/teachers on duty in the queue of a class.
+func (rs *RedisStore) GetDuties(class string) ([]*model.Duty, error) {
+	all, err := rs.Client.HGetAll(dutyKey(class)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	duties := []*model.Duty{}
+	for _, s := range all {
+		duty := &model.Duty{}
+		if err := json.Unmarshal([]byte(s), duty); err != nil {
+			return nil, err
+		}
+		duties = append(duties, duty)
+	}
+	return duties, nil
+}
+
+// SetQueueState saves whether and how students can join the queue of a class.
+func (rs *RedisStore) SetQueueState(state *model.QueueState) error {
+	j, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return rs.Client.Set(stateKey(state.Class), j, 0).Err()
+}
+
+// GetQueueState returns whether and how students can join the queue of a class;
+// a class that never had a state set is open without limits.
+func (rs *RedisStore) GetQueueState(class string) (*model.QueueState, error) {
+	s, err := rs.Client.Get(stateKey(class)).Result()
+	if err == redis.Nil {
+		return model.NewQueueState(class), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	state := &model.QueueState{}
+	if err := json.Unmarshal([]byte(s), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SetWaitStats saves the statistics the wait of the students in line of a class is estimated from.
+func (rs *RedisStore) SetWaitStats(class string, stats *model.WaitStats) error {
+	j, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return rs.Client.Set(waitKey(class), j, 0).Err()
+}
+
+// GetWaitStats returns the statistics the wait of the students in line of a class is estimated from,
+// or nil if they were never saved.
+func (rs *RedisStore) GetWaitStats(class string) (*model.WaitStats, error) {
+	s, err := rs.Client.Get(waitKey(class)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	stats := &model.WaitStats{}
+	if err := json.Unmarshal([]byte(s), stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetPosition returns the position in line of a student in the queue of a class,
+// or ErrQuestionNotFound.
+func (rs *RedisStore) GetPosition(class, id string) (*model.PositionInLine, error) {
+	// positions only count the students waiting, so they come from a snapshot of the queue
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		return nil, err
+	}
+
+	position, ok := queue.GetStudentPositions()[id]
+	if !ok {
+		return nil, ErrQuestionNotFound
+	}
+	return position, nil
+}
+
+// ClaimQuestions atomically claims questions in the queue of a class: `pick` chooses them from the queue as it is,
+// along with the students ahead of them in line they pass over, and `claim` changes every question chosen, e.g. to
+// claimed; each student passed over is counted as skipped at `at`, see `model.Question.Pass`. A copy of `event` is
+// recorded for every question claimed, and of `skipEvent` for every student passed over. Nothing changes if
+// `pick` or `claim` fails; it returns the questions claimed and the students passed over.
+func (rs *RedisStore) ClaimQuestions(class string, pick func(*model.QuestionQueue) ([]*model.Question, []*model.Question, error),
+	claim func(*model.Question) error, at time.Time, event, skipEvent *model.QueueEvent) ([]*model.Question, []*model.Question, error) {
+	const maxRetries = 10
+
+	for i := 0; i < maxRetries; i++ {
+		var claimed, skipped []*model.Question
+		err := rs.Client.Watch(func(tx *redis.Tx) error {
+			line, _, policy, err := readLine(tx, class)
+			if err != nil {
+				return err
+			}
+			claimed, skipped, err = pick(model.NewQuestionQueue(class, line, policy, at))
+			if err != nil {
+				return err
+			}
+
+			changed := make(map[string]string)
+			var events []string
+			record := func(q *model.Question, event *model.QueueEvent) error {
+				j, err := json.Marshal(q)
+				if err != nil {
+					return err
+				}
+				e, err := stampEvent(copyEvent(event), q, 0)
+				if err != nil {
+					return err
+				}
+				changed[q.ID] = string(j)
+				events = append(events, e)
+				return nil
+			}
+			for _, q := range claimed {
+				if err := claim(q); err != nil {
+					return err
+				}
+				if err := record(q, event); err != nil {
+					return err
+				}
+			}
+			for _, q := range skipped {
+				q.Pass(at)
+				if err := record(q, skipEvent); err != nil {
+					return err
+				}
+			}
+
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				for id, j := range changed {
+					pipe.HSet(questionsKey(class), id, j)
+				}
+				for _, e := range events {
+					logEvent(pipe, class, e)
+				}
+				return nil
+			})
+			return err
+		}, queueKey(class), questionsKey(class), policyKey(class))
+
+		if err == redis.TxFailedErr {
+			// the queue changed while claiming; try again
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		return claimed, skipped, nil
+	}
+
+	return nil, nil, redis.TxFailedErr
+}
+
+// MoveQuestion atomically moves a student waiting in line in the queue of a class to the given 1-based position
+// in the line ordered by the policy of the class, see `model.QuestionQueue.PlaceInLine`; any position past
+// the end of the queue moves the student to the back.
+// It returns the position the student ended up at and their new score, or ErrQuestionNotFound,
+// or model.ErrInvalidTransition if a TA/teacher is helping the student.
+func (rs *RedisStore) MoveQuestion(class, id string, position int) (int, float64, error) {
+	_, placed, score, err := rs.reorder(class, id, position, nil, nil)
+	return placed, score, err
+}
+
+// ReorderQuestion applies `update` to the question of a student in the queue of a class, like `UpdateQuestion`,
+// then moves them to the given 1-based position in line, like `MoveQuestion`, all in the same transaction,
+// so that nothing can change the question in between, and records the change as `event`, if given.
+// It returns the updated question and its new score.
+func (rs *RedisStore) ReorderQuestion(class, id string, position int, update func(*model.Question) error, event *model.QueueEvent) (*model.Question, float64, error) {
+	q, _, score, err := rs.reorder(class, id, position, update, event)
+	return q, score, err
+}
+
+// reorder is ReorderQuestion, `update` and `event` being optional, which also returns the position the student
+// ended up at. The queue is watched while the student is placed; it is retried if anything else changed the queue
+// in the meantime.
+func (rs *RedisStore) reorder(class, id string, position int, update func(*model.Question) error, event *model.QueueEvent) (*model.Question, int, float64, error) {
+	const maxRetries = 10
+
+	for i := 0; i < maxRetries; i++ {
+		var q *model.Question
+		var placed int
+		var score float64
+		err := rs.Client.Watch(func(tx *redis.Tx) error {
+			line, scores, policy, err := readLine(tx, class)
+			if err != nil {
+				return err
+			}
+			for _, question := range line {
+				if question.ID == id {
+					q = question
+				}
+			}
+			if q == nil {
+				return ErrQuestionNotFound
+			}
+
+			if update != nil {
+				if err := update(q); err != nil {
+					return err
+				}
+			}
+			var ok bool
+			placed, score, ok = model.NewQuestionQueue(class, line, policy, time.Now()).PlaceInLine(id, position, scores)
+			if !ok {
+				return model.ErrInvalidTransition
+			}
+
+			j, err := json.Marshal(q)
+			if err != nil {
+				return err
+			}
+			e, err := stampEvent(event, q, score)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+				pipe.HSet(questionsKey(class), id, j)
+				pipe.ZAdd(queueKey(class), redis.Z{Score: score, Member: id})
+				logEvent(pipe, class, e)
+				return nil
+			})
+			return err
+		}, queueKey(class), questionsKey(class), policyKey(class))
+
+		if err == redis.TxFailedErr {
+			// the queue changed while moving; try again
+			continue
+		} else if err != nil {
+			return nil, 0, 0, err
+		}
+		return q, placed, score, nil
+	}
+
+	return nil, 0, 0, redis.TxFailedErr
+}
+
+// readLine reads the questions in line in the queue of a class within a transaction, in the order of their scores,
+// along with their scores and the policy of the class; see `GetQueue`.
+func readLine(tx *redis.Tx, class string) ([]*model.Question, map[string]float64, *model.Policy, error) {
+	entries, err := tx.ZRangeWithScores(queueKey(class), 0, -1).Result()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	all, err := tx.HGetAll(questionsKey(class)).Result()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	policy := model.NewPolicy(class)
+	if s, err := tx.Get(policyKey(class)).Result(); err == nil {
+		if err := json.Unmarshal([]byte(s), policy); err != nil {
+			return nil, nil, nil, err
+		}
+	} else if err != redis.Nil {
+		return nil, nil, nil, err
+	}
+
+	var line []*model.Question
+	scores := make(map[string]float64)
+	for _, e := range entries {
+		id, _ := e.Member.(string)
+		s, ok := all[id]
+		if !ok {
+			// the hash lost the question, ignore
+			continue
+		}
+		q := &model.Question{}
+		if err := json.Unmarshal([]byte(s), q); err != nil {
+			return nil, nil, nil, err
+		}
+		line = append(line, q)
+		scores[id] = e.Score
+	}
+	return line, scores, policy, nil
+}
+
+// FlushEvents hands the events recorded in redis to `write`, queue by queue in the order of their sequence numbers,
+// and removes every event `write` took. It stops at the first error, so that the event and the ones after it
+// are handed over again by the next flush; `write` may get an event twice, e.g. from two flushes at once.
+func (rs *RedisStore) FlushEvents(write func(*model.QueueEvent) error) error {
+	const batch = 100
+
+	iter := rs.Client.Scan(0, eventsKey("*"), 0).Iterator()
+	for iter.Next() {
+		key := iter.Val()
+		for {
+			entries, err := rs.Client.LRange(key, 0, batch-1).Result()
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				break
+			}
+			for _, entry := range entries {
+				event, err := parseEvent(entry)
+				if err != nil {
+					return err
+				}
+				if err := write(event); err != nil {
+					return err
+				}
+				if err := rs.Client.LRem(key, 1, entry).Err(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return iter.Err()
+}
+
+// SeedEventSeq makes sure the events recorded from now on are numbered after `seq`,
+// e.g. the last sequence number of the event log when redis lost its counter.
+func (rs *RedisStore) SeedEventSeq(seq int64) error {
+	err := seedScript.Run(rs.Client, []string{eventSeqKey}, seq).Err()
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
+
+// GetQueueClasses returns the class codes of all queues in redis.
+func (rs *RedisStore) GetQueueClasses() ([]string, error) {
+	var classes []string
+	iter := rs.Client.Scan(0, queueKey("*"), 0).Iterator()
+	for iter.Next() {
+		class := strings.TrimPrefix(iter.Val(), queueKey(""))
+		// every queue has exactly one line key; skip the other keys of a queue such as its questions
+		if strings.Contains(class, ":") {
+			continue
+		}
+		classes = append(classes, class)
+	}
+	return classes, iter.Err()
+}
+
+// queueKey returns the redis key to use for the line of a class;
+// every class code gets its own queue.
+func queueKey(class string) string {
+	return "queue:" + class
+}
+
+// questionsKey returns the redis key to use for the questions in the queue of a class.
+func questionsKey(class string) string {
+	return queueKey(class) + ":questions"
+}
+
+// ownersKey returns the redis key of the hash of student IDs to the hashes of their tokens, see `NewOwnerToken`.
+func ownersKey(class string) string {
+	return queueKey(class) + ":owners"
+}
+
+// waitKey returns the redis key to use for the wait statistics of a class.
+func waitKey(class string) string {
+	return queueKey(class) + ":wait"
+}
+
+// stateKey returns the redis key to use for the state of the queue of a class.
+func stateKey(class string) string {
+	return queueKey(class) + ":state"
+}
+
+// dispatchKey returns the redis key to use for how the students in line of a class are handed out.
+func dispatchKey(class string) string {
+	return queueKey(class) + ":dispatch"
+}
+
+// dutyKey returns the redis key to use for the TA/teachers on duty in the queue of a class.
+func dutyKey(class string) string {
+	return queueKey(class) + ":duty"
+}
+
+// policyKey returns the redis key to use for the ordering policy of the queue of a class.
+func policyKey(class string) string {
+	return queueKey(class) + ":policy"
+}
+
+// joinKey returns the redis key to use for how the join codes of a class are made.
+func joinKey(class string) string {
+	return queueKey(class) + ":join"
+}
+
+// eventSeqKey is the redis key of the counter the events of every queue are numbered with.
+const eventSeqKey = "events:seq"
+
+// eventsKey returns the redis key to use for the events of the queue of a class waiting to be flushed.
+func eventsKey(class string) string {
+	return queueKey(class) + ":events"
+}
+
+// eventKeys returns the keys the scripts recording an event take.
+func eventKeys(class string) []string {
+	return []string{eventSeqKey, eventsKey(class)}
+}
+
+// queueKeys returns the keys every queue script takes.
+func queueKeys(class string) []string {
+	return []string{queueKey(class), questionsKey(class), ownersKey(class)}
+}
+
+// queueScore returns the score that places a question in line by the time it was asked.
+func queueScore(question *model.Question) string {
+	return strconv.FormatFloat(question.QueueScore(), 'f', -1, 64)
+}
+
+// copyEvent returns a copy of `event`, if any, to record about one question of a change to several.
+func copyEvent(event *model.QueueEvent) *model.QueueEvent {
+	if event == nil {
+		return nil
+	}
+	e := *event
+	return &e
+}
+
+// stampEvent fills in what `event` records about the change that left a question, if any, as it is,
+// at `score` if the change gave it a new place in line, and returns the event JSON encoded, or "" for no event.
+// The sequence number of the event is only known once it is recorded, see `logEvent`.
+func stampEvent(event *model.QueueEvent, question *model.Question, score float64) (string, error) {
+	if event == nil {
+		return "", nil
+	}
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if question != nil {
+		event.Class = question.Class
+		event.StudentID = question.ID
+		event.Question = question
+	}
+	event.Score = score
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	j, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+	return string(j), nil
+}
+
+// logEvent records the JSON encoded event `e`, if any, within the transaction of `pipe`.
+func logEvent(pipe redis.Pipeliner, class, e string) {
+	if len(e) != 0 {
+		logScript.Eval(pipe, eventKeys(class), e)
+	}
+}
+
+// parseEvent returns the event of an entry of the events of a queue, numbered with its sequence number.
+func parseEvent(entry string) (*model.QueueEvent, error) {
+	i := strings.Index(entry, " ")
+	if i < 0 {
+		return nil, errors.New("invalid event entry: " + entry)
+	}
+	seq, err := strconv.ParseInt(entry[:i], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &model.QueueEvent{}
+	if err := json.Unmarshal([]byte(entry[i+1:]), event); err != nil {
+		return nil, err
+	}
+	event.Seq = seq
+	return event, nil
+}
+
+// NewOwnerToken returns a new secret token a student proves they own their question with;
+// only its hash is kept.
+func NewOwnerToken() (string, error) {
+	b, err := GenerateRandomBytes(idLength)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// HashOwnerToken returns the hash of a token kept in redis and mongo, or "" for no token.
+func HashOwnerToken(token string) string {
+	if len(token) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,586 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"questionqueue/src/model"
+	"sync"
+	"testing"
+	"time"
+)
+
+// connector
+const redisAddr = "localhost:6379"
+
+// newTestRedisStore connects to a local redis and returns a store along with
+// a class code of its own, skipping the test if redis is not running.
+func newTestRedisStore(t *testing.T) (*RedisStore, string) {
+	client := NewRedisClient(redisAddr)
+	if err := client.Ping().Err(); err != nil {
+		t.Skipf("redis is not available at %v: %v", redisAddr, err)
+	}
+
+	class := fmt.Sprintf("test-%v", time.Now().UnixNano())
+	t.Cleanup(func() {
+		client.Del(append(queueKeys(class), waitKey(class), stateKey(class), policyKey(class), dispatchKey(class), dutyKey(class), joinKey(class), eventsKey(class))...)
+		client.Close()
+	})
+	return NewRedisStore(client, time.Hour), class
+}
+
+// withdraw is the update of a student withdrawing their question, which takes it out of line.
+func withdraw(q *model.Question) error {
+	return q.Transition(model.StatusWithdrawn, "", time.Now())
+}
+
+func TestRedisStore_EnqueueConcurrently(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	const students = 500
+
+	var wg sync.WaitGroup
+	errs := make(chan error, students)
+	for i := 0; i < students; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := rs.Enqueue(&model.Question{
+				ID:        fmt.Sprintf("student-%v", i),
+				Class:     class,
+				CreatedAt: time.Now(),
+			})
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatalf("unexpected error when reading the queue: %v", err)
+	}
+
+	if len(queue.Queue) != students {
+		t.Fatalf("expected %v students in line, got %v", students, len(queue.Queue))
+	}
+
+	seen := make(map[string]bool)
+	for _, q := range queue.Queue {
+		if seen[q.ID] {
+			t.Errorf("student %v is in line more than once", q.ID)
+		}
+		seen[q.ID] = true
+	}
+}
+
+func TestRedisStore_EnqueueWithdrawConcurrently(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	const students = 200
+
+	// half of the students are in line before the others join and they leave
+	for i := 0; i < students/2; i++ {
+		if _, err := rs.Enqueue(&model.Question{ID: fmt.Sprintf("leaving-%v", i), Class: class, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, students*2)
+	for i := 0; i < students/2; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			_, err := rs.Enqueue(&model.Question{ID: fmt.Sprintf("joining-%v", i), Class: class, CreatedAt: time.Now()})
+			errs <- err
+		}(i)
+		// the student withdraws twice at once; only one of them takes the question out of line
+		for j := 0; j < 2; j++ {
+			go func(i int) {
+				defer wg.Done()
+				if _, err := rs.UpdateQuestion(class, fmt.Sprintf("leaving-%v", i), withdraw, nil); err != nil && err != ErrQuestionNotFound {
+					errs <- err
+				}
+			}(i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatalf("unexpected error when reading the queue: %v", err)
+	}
+
+	if len(queue.Queue) != students/2 {
+		t.Fatalf("expected %v students in line, got %v", students/2, len(queue.Queue))
+	}
+	for _, q := range queue.Queue {
+		if q.ID[:len("joining")] != "joining" {
+			t.Errorf("student %v should have left the line", q.ID)
+		}
+	}
+}
+
+func TestRedisStore_Enqueue_Duplicate(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	q := &model.Question{ID: "student", Class: class, Topic: "first", CreatedAt: time.Now()}
+	if _, err := rs.Enqueue(q); err != nil {
+		t.Fatalf("unexpected error when enqueueing: %v", err)
+	}
+
+	again := &model.Question{ID: "student", Class: class, Topic: "second", CreatedAt: time.Now()}
+	existing, err := rs.Enqueue(again)
+	if err != ErrQuestionExists {
+		t.Fatalf("expected %v, got %v", ErrQuestionExists, err)
+	}
+	if existing.Topic != "first" {
+		t.Errorf("expected the existing question, got %v", existing.Topic)
+	}
+}
+
+func TestRedisStore_UpdateQuestion(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	start := time.Now()
+	for i, id := range []string{"a", "b"} {
+		q := &model.Question{ID: id, Class: class, CreatedAt: start.Add(time.Duration(i) * time.Millisecond)}
+		if _, err := rs.Enqueue(q); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	// concurrent updates of the same question must all be applied
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := rs.UpdateQuestion(class, "a", func(q *model.Question) error {
+				q.Description += "x"
+				return nil
+			}, nil); err != nil {
+				t.Errorf("unexpected error when updating: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatalf("unexpected error when reading the queue: %v", err)
+	}
+	if queue.Queue[0].ID != "a" || queue.Queue[0].Description != "xxxxx" {
+		t.Errorf("expected a in front with all updates, got %v with %v", queue.Queue[0].ID, queue.Queue[0].Description)
+	}
+
+	if _, err := rs.UpdateQuestion(class, "nobody", func(q *model.Question) error { return nil }, nil); err != ErrQuestionNotFound {
+		t.Errorf("expected %v, got %v", ErrQuestionNotFound, err)
+	}
+}
+
+func TestRedisStore_MoveQuestion(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	start := time.Now()
+	for i, id := range []string{"a", "b", "c", "d"} {
+		q := &model.Question{ID: id, Class: class, CreatedAt: start.Add(time.Duration(i) * time.Millisecond)}
+		if _, err := rs.Enqueue(q); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name     string
+		id       string
+		position int
+		expected string
+	}{
+		{"To the back", "a", 100, "bcda"},
+		{"To the front", "d", 1, "dbca"},
+		{"To the middle", "a", 2, "dabc"},
+		{"Behind itself", "b", 3, "dabc"},
+	}
+
+	for _, c := range cases {
+		if _, _, err := rs.MoveQuestion(class, c.id, c.position); err != nil {
+			t.Fatalf("%v: unexpected error when moving: %v", c.name, err)
+		}
+		queue, err := rs.GetQueue(class)
+		if err != nil {
+			t.Fatalf("%v: unexpected error when reading the queue: %v", c.name, err)
+		}
+		got := ""
+		for _, q := range queue.Queue {
+			got += q.ID
+		}
+		if got != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, got)
+		}
+	}
+
+	if _, _, err := rs.MoveQuestion(class, "nobody", 1); err != ErrQuestionNotFound {
+		t.Errorf("expected %v, got %v", ErrQuestionNotFound, err)
+	}
+}
+
+func TestRedisStore_MoveQuestion_Policy(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	if err := rs.SetPolicy(&model.Policy{Class: class, Name: model.PolicyFirstOfSession}, nil); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for i, id := range []string{"a", "b", "c"} {
+		q := &model.Question{ID: id, Class: class, CreatedAt: start.Add(time.Duration(i) * time.Millisecond)}
+		// a and c asked before in the session, so b is ahead of them
+		if id != "b" {
+			q.PriorQuestions = 1
+		}
+		if _, err := rs.Enqueue(q); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	// the front of the line is the front of the repeat askers
+	position, _, err := rs.MoveQuestion(class, "c", 1)
+	if err != nil {
+		t.Fatalf("unexpected error when moving: %v", err)
+	}
+	if position != 2 {
+		t.Errorf("expected c at 2, got %v", position)
+	}
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := queue.GetStudentPositions()
+	if positions["b"].Position != 1 || positions["c"].Position != 2 || positions["a"].Position != 3 {
+		t.Errorf("expected bca, got b at %v, c at %v and a at %v", positions["b"].Position, positions["c"].Position, positions["a"].Position)
+	}
+
+	if _, err := rs.UpdateQuestion(class, "a", func(q *model.Question) error {
+		q.Status = model.StatusClaimed
+		return nil
+	}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rs.MoveQuestion(class, "a", 1); err != model.ErrInvalidTransition {
+		t.Errorf("expected %v, got %v", model.ErrInvalidTransition, err)
+	}
+}
+
+func TestRedisStore_UpdateQuestion_Done(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	if _, err := rs.Enqueue(&model.Question{ID: "student", Class: class, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("unexpected error when enqueueing: %v", err)
+	}
+
+	for _, status := range []string{model.StatusClaimed, model.StatusResolved} {
+		if _, err := rs.UpdateQuestion(class, "student", func(q *model.Question) error {
+			return q.Transition(status, "ta", time.Now())
+		}, nil); err != nil {
+			t.Fatalf("unexpected error when changing to %v: %v", status, err)
+		}
+
+		queue, err := rs.GetQueue(class)
+		if err != nil {
+			t.Fatalf("unexpected error when reading the queue: %v", err)
+		}
+		switch status {
+		case model.StatusClaimed:
+			if len(queue.Queue) != 0 || len(queue.Helping) != 1 {
+				t.Errorf("expected the student to be helped, got %v waiting and %v helped", len(queue.Queue), len(queue.Helping))
+			}
+		case model.StatusResolved:
+			if len(queue.Queue) != 0 || len(queue.Helping) != 0 {
+				t.Errorf("expected the student to leave the queue, got %v waiting and %v helped", len(queue.Queue), len(queue.Helping))
+			}
+		}
+	}
+}
+
+func TestRedisStore_EnqueueIfOpen(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	state := &model.QueueState{Class: class, Status: model.QueueOpen, MaxLength: 2}
+	for _, id := range []string{"a", "b"} {
+		if _, err := rs.EnqueueIfOpen(&model.Question{ID: id, Class: class, CreatedAt: time.Now()}, state, "", nil); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	if _, err := rs.EnqueueIfOpen(&model.Question{ID: "c", Class: class, CreatedAt: time.Now()}, state, "", nil); err != model.ErrQueueFull {
+		t.Errorf("expected %v, got %v", model.ErrQueueFull, err)
+	}
+
+	// students already in line still get their question back while the queue does not admit new ones
+	state.Status = model.QueueClosed
+	if _, err := rs.EnqueueIfOpen(&model.Question{ID: "a", Class: class, CreatedAt: time.Now()}, state, "", nil); err != ErrQuestionExists {
+		t.Errorf("expected %v, got %v", ErrQuestionExists, err)
+	}
+	if _, err := rs.EnqueueIfOpen(&model.Question{ID: "d", Class: class, CreatedAt: time.Now()}, state, "", nil); err != model.ErrQueueClosed {
+		t.Errorf("expected %v, got %v", model.ErrQueueClosed, err)
+	}
+}
+
+func TestRedisStore_VerifyOwner(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	token, err := NewOwnerToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.EnqueueIfOpen(&model.Question{ID: "a", Class: class, CreatedAt: time.Now()}, nil, token, nil); err != nil {
+		t.Fatalf("unexpected error when enqueueing: %v", err)
+	}
+	if _, err := rs.Enqueue(&model.Question{ID: "b", Class: class, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("unexpected error when enqueueing: %v", err)
+	}
+
+	cases := []struct {
+		name, id, token string
+		expected        error
+	}{
+		{"Owner", "a", token, nil},
+		{"Wrong token", "a", token + "x", ErrNotOwner},
+		{"No token", "a", "", ErrNotOwner},
+		{"Question without a token", "b", token, ErrNotOwner},
+		{"Not in line", "c", token, ErrQuestionNotFound},
+	}
+	for _, c := range cases {
+		if err := rs.VerifyOwner(class, c.id, c.token); err != c.expected {
+			t.Errorf("%v: expected %v, got %v", c.name, c.expected, err)
+		}
+	}
+
+	// the token goes with the question
+	if _, err := rs.UpdateQuestion(class, "a", withdraw, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rs.Enqueue(&model.Question{ID: "a", Class: class, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.VerifyOwner(class, "a", token); err != ErrNotOwner {
+		t.Errorf("expected the token of a withdrawn question to be forgotten, got %v", err)
+	}
+}
+
+func TestRedisStore_Restore(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	token, err := NewOwnerToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a question put back in line from mongo, even in a closed queue, is still owned by its student
+	q := &model.Question{ID: "a", Class: class, CreatedAt: time.Now(), OwnerHash: HashOwnerToken(token)}
+	if _, err := rs.Restore(q, nil); err != nil {
+		t.Fatalf("unexpected error when restoring: %v", err)
+	}
+	if err := rs.VerifyOwner(class, "a", token); err != nil {
+		t.Errorf("expected the owner of a restored question to be verified, got %v", err)
+	}
+	if _, err := rs.Restore(q, nil); err != ErrQuestionExists {
+		t.Errorf("expected %v, got %v", ErrQuestionExists, err)
+	}
+}
+
+func TestRedisStore_ReorderQuestion(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	start := time.Now()
+	for i, id := range []string{"a", "b", "c"} {
+		q := &model.Question{ID: id, Class: class, Status: model.StatusWaiting, CreatedAt: start.Add(time.Duration(i) * time.Millisecond)}
+		if id == "a" {
+			q.Status = model.StatusClaimed
+			q.ClaimedBy = "ta"
+		}
+		if _, err := rs.Enqueue(q); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	// the claimed student goes back to waiting at the back of the line in one go
+	q, _, err := rs.ReorderQuestion(class, "a", 100, func(q *model.Question) error {
+		return q.Transition(model.StatusWaiting, "ta", time.Now())
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error when reordering: %v", err)
+	}
+	if q.CurrentStatus() != model.StatusWaiting {
+		t.Errorf("expected the question to be waiting, got %v", q.CurrentStatus())
+	}
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ""
+	for _, q := range queue.Queue {
+		got += q.ID
+	}
+	if got != "bca" {
+		t.Errorf("expected bca, got %v", got)
+	}
+
+	// an update that fails changes nothing
+	if _, _, err := rs.ReorderQuestion(class, "c", 1, func(q *model.Question) error {
+		return model.ErrInvalidTransition
+	}, nil); err != model.ErrInvalidTransition {
+		t.Errorf("expected %v, got %v", model.ErrInvalidTransition, err)
+	}
+	if position, err := rs.GetPosition(class, "c"); err != nil || position.Position != 2 {
+		t.Errorf("expected c to stay at 2, got %v and %v", position, err)
+	}
+
+	if _, _, err := rs.ReorderQuestion(class, "nobody", 1, nil, nil); err != ErrQuestionNotFound {
+		t.Errorf("expected %v, got %v", ErrQuestionNotFound, err)
+	}
+}
+
+func TestRedisStore_ClaimQuestions(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	const students = 10
+	now := time.Now()
+	for i := 0; i < students; i++ {
+		q := &model.Question{ID: fmt.Sprintf("student-%02d", i), Class: class, CreatedAt: now.Add(time.Duration(i) * time.Second)}
+		if _, err := rs.Enqueue(q); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+
+	// TA/teachers claiming students behind the first at once pass them over no more often than allowed
+	dispatch := &model.Dispatch{Mode: model.DispatchProximity, FallbackMinutes: 15, MaxSkips: 3, MaxDelayMinutes: 5}
+	var wg sync.WaitGroup
+	errs := make(chan error, students-1)
+	for i := 1; i < students; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, err := rs.ClaimQuestions(class, func(queue *model.QuestionQueue) ([]*model.Question, []*model.Question, error) {
+				return dispatch.Claim(queue, []string{fmt.Sprintf("student-%02d", i)}, now)
+			}, func(q *model.Question) error {
+				return q.Transition(model.StatusClaimed, fmt.Sprintf("ta-%v", i), now)
+			}, now, nil, nil)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	claims := 0
+	for err := range errs {
+		switch err {
+		case nil:
+			claims++
+		case model.ErrOverdueAhead:
+		default:
+			t.Fatalf("unexpected error when claiming: %v", err)
+		}
+	}
+	if claims != dispatch.MaxSkips {
+		t.Errorf("expected %v claims, got %v", dispatch.MaxSkips, claims)
+	}
+
+	queue, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first := queue.Queue[0]; first.ID != "student-00" || first.Skips != dispatch.MaxSkips || !first.SkippedAt.Equal(now) {
+		t.Errorf("expected student-00 passed over %v times, got %v passed over %v times at %v", dispatch.MaxSkips, first.ID, first.Skips, first.SkippedAt)
+	}
+	if len(queue.Helping) != claims {
+		t.Errorf("expected %v students being helped, got %v", claims, len(queue.Helping))
+	}
+
+	// a failed claim changes nothing
+	if _, _, err := rs.ClaimQuestions(class, func(queue *model.QuestionQueue) ([]*model.Question, []*model.Question, error) {
+		return queue.Queue[:1], queue.Queue[1:2], nil
+	}, func(q *model.Question) error {
+		return model.ErrInvalidTransition
+	}, now, nil, nil); err != model.ErrInvalidTransition {
+		t.Errorf("expected %v, got %v", model.ErrInvalidTransition, err)
+	}
+	after, err := rs.GetQueue(class)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Queue[0].CurrentStatus() != model.StatusWaiting || after.Queue[1].Skips != queue.Queue[1].Skips {
+		t.Errorf("expected the queue unchanged, got %v with %v skips behind", after.Queue[0].CurrentStatus(), after.Queue[1].Skips)
+	}
+}
+
+func TestRedisStore_FlushEvents(t *testing.T) {
+	rs, class := newTestRedisStore(t)
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := rs.EnqueueIfOpen(&model.Question{ID: id, Class: class, CreatedAt: time.Now()}, nil, "", &model.QueueEvent{Type: "question-new"}); err != nil {
+			t.Fatalf("unexpected error when enqueueing: %v", err)
+		}
+	}
+	if _, err := rs.UpdateQuestion(class, "a", func(q *model.Question) error {
+		return q.Transition(model.StatusClaimed, "ta", time.Now())
+	}, &model.QueueEvent{Type: "question-claimed"}); err != nil {
+		t.Fatalf("unexpected error when updating: %v", err)
+	}
+
+	// the events of a write that fails stay in redis
+	failed := errors.New("mongo is down")
+	if err := rs.FlushEvents(func(e *model.QueueEvent) error {
+		if e.Class == class {
+			return failed
+		}
+		return nil
+	}); err != failed {
+		t.Fatalf("expected %v, got %v", failed, err)
+	}
+
+	var events []*model.QueueEvent
+	if err := rs.FlushEvents(func(e *model.QueueEvent) error {
+		if e.Class == class {
+			events = append(events, e)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error when flushing: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %v", len(events))
+	}
+	for i, want := range []string{"a", "b", "a"} {
+		if events[i].StudentID != want {
+			t.Errorf("expected event %v about %v, got %v", i, want, events[i].StudentID)
+		}
+		if i > 0 && events[i].Seq <= events[i-1].Seq {
+			t.Errorf("expected increasing sequence numbers, got %v after %v", events[i].Seq, events[i-1].Seq)
+		}
+	}
+	if events[2].Question.CurrentStatus() != model.StatusClaimed {
+		t.Errorf("expected the claimed question in the last event, got %v", events[2].Question.CurrentStatus())
+	}
+
+	// nothing is left to flush
+	if err := rs.FlushEvents(func(e *model.QueueEvent) error {
+		if e.Class == class {
+			t.Errorf("unexpected event %v flushed twice", e.Seq)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error when flushing: %v", err)
+	}
+}
@@ -50,49 +50,6 @@ func (rs *RedisStore) Save(sid SessionID, sessionState interface{}) error {
 	return nil
 }
 
-func (rs *RedisStore) SetQueue(sessionState interface{}) error {
-
-	j, err := json.Marshal(sessionState)
-	if err != nil {
-		return err
-	}
-
-	// do not expire question queue
-	rs.Client.Set("queue", j, 0)
-	return nil
-}
-
-func (rs *RedisStore) GetQueue(sessionState interface{}) error {
-	// get the previously-saved session state data from redis,
-	// unmarshal it back into the `sessionState` parameter
-	// and reset the expiry time, so that it doesn't get deleted until
-	// the SessionDuration has elapsed.
-
-	// for extra-credit using the Pipeline feature of the redis
-	// package to do both the get and the reset of the expiry time
-	// in just one network round trip!
-
-	pipeline := rs.Client.Pipeline()
-	pipe := pipeline.Get("queue")
-	// do not expire question queue
-	pipeline.Expire("queue", 0)
-
-	if _, err := pipeline.Exec(); err != nil {
-		return err
-	}
-
-	if s, err := pipe.Result(); err != nil {
-		return err
-	} else {
-		if err = json.Unmarshal([]byte(s), sessionState); err != nil {
-			// cannot unmarshal
-			return err
-		} else {
-			return nil
-		}
-	}
-}
-
 // Get populates `sessionState` with the data previously saved
 // for the given SessionID
 func (rs *RedisStore) Get(sid SessionID, sessionState interface{}) error {
@@ -16,9 +16,9 @@ var ErrNoSessionID = errors.New("no session ID found in " + headerAuthorization
 // ErrInvalidScheme is used when the authorization scheme is not supported
 var ErrInvalidScheme = errors.New("authorization scheme not supported")
 
-// BeginSession creates a new SessionID, saves the `sessionState` to the store, adds an
+// BeginSession creates a new SessionID signed with the current key, saves the `sessionState` to the store, adds an
 // Authorization header to the response with the SessionID, and returns the new SessionID
-func BeginSession(signingKey string, store Store, sessionState State, w http.ResponseWriter) (SessionID, error) {
+func BeginSession(keys *SigningKeys, store Store, sessionState State, w http.ResponseWriter) (SessionID, error) {
 	// - create a new SessionID
 	// - save the sessionState to the store
 	//   where "<sessionID>" is replaced with the newly-created SessionID
@@ -26,7 +26,7 @@ func BeginSession(signingKey string, store Store, sessionState State, w http.Res
 	// - add a header to the ResponseWriter that looks like this:
 	//     "Authorization: Bearer <sessionID>"
 
-	sid, err := NewSessionID(signingKey)
+	sid, err := keys.NewSessionID()
 	if err != nil {
 		return InvalidSessionID, err
 	}
@@ -39,8 +39,9 @@ func BeginSession(signingKey string, store Store, sessionState State, w http.Res
 	return sid, nil
 }
 
-// GetSessionID extracts and validates the SessionID from the request headers
-func GetSessionID(r *http.Request, signingKey string) (SessionID, error) {
+// GetSessionID extracts and validates the SessionID from the request headers,
+// with the current key or a retired key still accepted
+func GetSessionID(r *http.Request, keys *SigningKeys) (SessionID, error) {
 
 	// get the value of the Authorization header,
 	id := r.Header.Get("Authorization")
@@ -63,7 +64,7 @@ func GetSessionID(r *http.Request, signingKey string) (SessionID, error) {
 
 		// If it's valid, return the SessionID. If not return the validation error.
 		id = s[len(s)-1]
-		sid, err := ValidateID(id, signingKey)
+		sid, err := keys.ValidateID(id)
 		if err != nil {
 			return InvalidSessionID, ErrInvalidID
 		} else {
@@ -78,10 +79,10 @@ func GetSessionID(r *http.Request, signingKey string) (SessionID, error) {
 // GetState extracts the SessionID from the request,
 // gets the associated state from the provided store into
 // the `sessionState` parameter, and returns the SessionID
-func GetState(r *http.Request, signingKey string, store Store, sessionState interface{}) (SessionID, error) {
+func GetState(r *http.Request, keys *SigningKeys, store Store, sessionState interface{}) (SessionID, error) {
 	// get the SessionID from the request, and get the data
 	// associated with that SessionID from the store.
-	sid, err := GetSessionID(r, signingKey)
+	sid, err := GetSessionID(r, keys)
 	if err != nil {
 		return InvalidSessionID, err
 	}
@@ -96,11 +97,11 @@ func GetState(r *http.Request, signingKey string, store Store, sessionState inte
 // EndSession extracts the SessionID from the request,
 // and deletes the associated data in the provided store, returning
 // the extracted SessionID.
-func EndSession(r *http.Request, signingKey string, store Store) (SessionID, error) {
+func EndSession(r *http.Request, keys *SigningKeys, store Store) (SessionID, error) {
 	// get the SessionID from the request, and delete the
 	// data associated with it in the store.
 
-	sid, err := GetSessionID(r, signingKey)
+	sid, err := GetSessionID(r, keys)
 	if err != nil {
 		return InvalidSessionID, err
 	}
@@ -1,12 +1,13 @@
 package session
 
 import (
-	"bytes"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"errors"
+	"strings"
+	"time"
 )
 
 // InvalidSessionID represents an empty, invalid session ID
@@ -17,7 +18,7 @@ const idLength = 32
 
 // signedLength is the full length of the signed session ID
 // (ID portion plus signature)
-//const signedLength = idLength + sha256.Size
+const signedLength = idLength + sha256.Size
 
 // SessionID represents a valid, digitally-signed session ID.
 // This is a base64 URL encoded string created from a byte slice
@@ -28,13 +29,89 @@ const idLength = 32
 // +-----------------------------------------------------+
 // |...32 crypto random bytes...|HMAC hash of those bytes|
 // +-----------------------------------------------------+
+// Validating a session ID only takes the signing key, so any replica can.
 type SessionID string
 
 // ErrInvalidID is returned when an invalid session id is passed to ValidateID()
 var ErrInvalidID = errors.New("invalid Session ID")
 
-// SID for current session
-var generatedSID = ""
+// ErrInvalidRetiredKey is returned when a retired signing key cannot be parsed, see `ParseRetiredKeys`.
+var ErrInvalidRetiredKey = errors.New("invalid retired signing key")
+
+// RetiredKey is a signing key new session IDs are no longer signed with;
+// the session IDs it signed stay valid until `Until`.
+type RetiredKey struct {
+	Key   string
+	Until time.Time
+}
+
+// SigningKeys are the keys session IDs are signed with. Rotating the key moves the current key
+// to the retired keys for a grace period, so the sessions begun before still work meanwhile.
+type SigningKeys struct {
+	Current string
+	Retired []RetiredKey
+}
+
+// NewSigningKeys returns the signing keys signing new session IDs with `current`.
+func NewSigningKeys(current string, retired ...RetiredKey) *SigningKeys {
+	return &SigningKeys{Current: current, Retired: retired}
+}
+
+// ParseRetiredKeys parses a comma separated list of retired keys, each one
+// as `<key>@<RFC 3339 time it stops being accepted>`; an empty list has no keys.
+func ParseRetiredKeys(s string) ([]RetiredKey, error) {
+	var keys []RetiredKey
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.TrimSpace(k); len(k) == 0 {
+			continue
+		}
+
+		i := strings.LastIndex(k, "@")
+		if i <= 0 {
+			return nil, ErrInvalidRetiredKey
+		}
+		until, err := time.Parse(time.RFC3339, k[i+1:])
+		if err != nil {
+			return nil, ErrInvalidRetiredKey
+		}
+		keys = append(keys, RetiredKey{Key: k[:i], Until: until})
+	}
+	return keys, nil
+}
+
+// Accepted returns the current key followed by the retired keys still accepted at a given time.
+func (keys *SigningKeys) Accepted(at time.Time) []string {
+	accepted := []string{keys.Current}
+	for _, k := range keys.Retired {
+		if !at.After(k.Until) {
+			accepted = append(accepted, k.Key)
+		}
+	}
+	return accepted
+}
+
+// NewSessionID returns a new session ID signed with the current key.
+func (keys *SigningKeys) NewSessionID() (SessionID, error) {
+	return NewSessionID(keys.Current)
+}
+
+// ValidateID validates a session ID with the current key, or a retired key still accepted now.
+func (keys *SigningKeys) ValidateID(id string) (SessionID, error) {
+	if sid, err := ValidateID(id, keys.Current); err == nil {
+		return sid, nil
+	}
+
+	now := time.Now()
+	for _, k := range keys.Retired {
+		if now.After(k.Until) {
+			continue
+		}
+		if sid, err := ValidateID(id, k.Key); err == nil {
+			return sid, nil
+		}
+	}
+	return InvalidSessionID, ErrInvalidID
+}
 
 // NewSessionID creates and returns a new digitally-signed session ID,
 // using `signingKey` as the HMAC signing key. An error is returned only
@@ -59,11 +136,8 @@ func NewSessionID(signingKey string) (SessionID, error) {
 		return InvalidSessionID, err
 	}
 
-	combined := append(randByte, GenerateRandomHMAC(signingKey)...)
-	sid := base64.URLEncoding.EncodeToString(combined)
-
-	generatedSID = sid
-	return SessionID(sid), nil
+	combined := append(randByte, GenerateHMAC(randByte, signingKey)...)
+	return SessionID(base64.URLEncoding.EncodeToString(combined)), nil
 }
 
 // ValidateID validates the string in the `id` parameter
@@ -78,24 +152,19 @@ func ValidateID(id string, signingKey string) (SessionID, error) {
 	// return the entire `id` parameter as a SessionID type.
 	// If not, return InvalidSessionID and ErrInvalidID.
 
-	if !IdHasMutated(id) {
+	if len(signingKey) == 0 {
 		return InvalidSessionID, ErrInvalidID
 	}
 
 	decodedID, err := DecodeSessionID(id)
-	if err != nil {
+	if err != nil || len(decodedID) != signedLength {
 		return InvalidSessionID, ErrInvalidID
 	}
 
-	// ID portion of the byte slice
-	decodedID = decodedID[idLength:]
-
-	match := hmac.Equal(decodedID, GenerateRandomHMAC(signingKey))
-	if match {
-		return SessionID(id), nil
-	} else {
+	if !hmac.Equal(decodedID[idLength:], GenerateHMAC(decodedID[:idLength], signingKey)) {
 		return InvalidSessionID, ErrInvalidID
 	}
+	return SessionID(id), nil
 }
 
 //// string returns a string representation of the sessionID
@@ -118,9 +187,8 @@ func GenerateRandomBytes(n int) ([]byte, error) {
 	}
 }
 
-func GenerateRandomHMAC(signingKey string) []byte {
-	buf := new(bytes.Buffer)
-	b := buf.Bytes()
+// GenerateHMAC returns the HMAC-SHA256 of `b` with `signingKey`, the signature of a session ID.
+func GenerateHMAC(b []byte, signingKey string) []byte {
 	h := hmac.New(sha256.New, []byte(signingKey))
 	h.Write(b)
 
@@ -134,7 +202,3 @@ func DecodeSessionID(sid string) ([]byte, error) {
 	}
 	return d, nil
 }
-
-func IdHasMutated(id string) bool {
-	return generatedSID == id
-}
@@ -0,0 +1,148 @@
+package session
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateID_ManySessions(t *testing.T) {
+	const sessions = 100
+
+	// every replica validates the IDs any of them signed, whoever logged in last
+	issuer, replica := NewSigningKeys("key"), NewSigningKeys("key")
+
+	var wg sync.WaitGroup
+	ids := make(chan SessionID, sessions)
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sid, err := issuer.NewSessionID()
+			if err != nil {
+				t.Error(err)
+			}
+			ids <- sid
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	seen := map[SessionID]bool{}
+	for sid := range ids {
+		if seen[sid] {
+			t.Errorf("session ID %v issued twice", sid)
+		}
+		seen[sid] = true
+		if _, err := replica.ValidateID(string(sid)); err != nil {
+			t.Errorf("expected session ID %v to be valid, got %v", sid, err)
+		}
+	}
+}
+
+func TestValidateID_Invalid(t *testing.T) {
+	sid, err := NewSessionID("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := DecodeSessionID(string(sid))
+	b[0] ^= 1
+	tampered := base64.URLEncoding.EncodeToString(b)
+
+	cases := []struct {
+		name string
+		id   string
+		key  string
+	}{
+		{"Other key", string(sid), "other key"},
+		{"No key", string(sid), ""},
+		{"Tampered", tampered, "key"},
+		{"Unsigned", base64.URLEncoding.EncodeToString(b[:idLength]), "key"},
+		{"Signature of nothing", base64.URLEncoding.EncodeToString(append(b[:idLength:idLength], GenerateHMAC(nil, "key")...)), "key"},
+		{"Not base64", "not a session ID!", "key"},
+		{"Empty", "", "key"},
+	}
+
+	for _, c := range cases {
+		if _, err := ValidateID(c.id, c.key); err != ErrInvalidID {
+			t.Errorf("%v: expected %v, got %v", c.name, ErrInvalidID, err)
+		}
+	}
+}
+
+func TestSigningKeys_Rotation(t *testing.T) {
+	old, err := NewSessionID("old key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	older, err := NewSessionID("older key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := NewSigningKeys("new key",
+		RetiredKey{Key: "old key", Until: time.Now().Add(time.Hour)},
+		RetiredKey{Key: "older key", Until: time.Now().Add(-time.Hour)})
+
+	if _, err := keys.ValidateID(string(old)); err != nil {
+		t.Errorf("expected a session ID of a key within its grace period to be valid, got %v", err)
+	}
+	if _, err := keys.ValidateID(string(older)); err != ErrInvalidID {
+		t.Errorf("expected a session ID of a key past its grace period to be invalid, got %v", err)
+	}
+
+	sid, err := keys.NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateID(string(sid), "new key"); err != nil {
+		t.Errorf("expected new session IDs to be signed with the current key, got %v", err)
+	}
+	if accepted := keys.Accepted(time.Now()); len(accepted) != 2 || accepted[0] != "new key" || accepted[1] != "old key" {
+		t.Errorf("expected the current key and the key within its grace period, got %v", accepted)
+	}
+}
+
+func TestGetSessionID(t *testing.T) {
+	keys := NewSigningKeys("key")
+	sid, err := keys.NewSessionID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(headerAuthorization, schemeBearer+string(sid))
+	if got, err := GetSessionID(r, keys); err != nil || got != sid {
+		t.Errorf("expected %v, got %v, %v", sid, got, err)
+	}
+
+	r = httptest.NewRequest("GET", "/?"+url.Values{paramAuthorization: {schemeBearer + string(sid)}}.Encode(), nil)
+	if got, err := GetSessionID(r, keys); err != nil || got != sid {
+		t.Errorf("expected %v from the query, got %v, %v", sid, got, err)
+	}
+}
+
+func TestParseRetiredKeys(t *testing.T) {
+	keys, err := ParseRetiredKeys("a@b@2026-01-02T15:04:05Z, c@2026-01-03T00:00:00+01:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Key != "a@b" || keys[1].Key != "c" ||
+		!keys[1].Until.Equal(time.Date(2026, 1, 2, 23, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected keys %+v", keys)
+	}
+
+	if keys, err := ParseRetiredKeys(""); err != nil || len(keys) != 0 {
+		t.Errorf("expected no keys, got %+v, %v", keys, err)
+	}
+
+	for _, s := range []string{"a", "@2026-01-02T15:04:05Z", "a@tomorrow"} {
+		if _, err := ParseRetiredKeys(s); err != ErrInvalidRetiredKey {
+			t.Errorf("%v: expected %v, got %v", s, ErrInvalidRetiredKey, err)
+		}
+	}
+}
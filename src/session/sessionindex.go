@@ -0,0 +1,157 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/go-redis/redis"
+	"sort"
+	"time"
+)
+
+// SessionInfo is a session of a user as listed to them: when it started and from which device.
+// Sessions are told apart by their `ID`, a hash of the session ID, which cannot be used to sign in.
+type SessionInfo struct {
+	ID           string    `json:"id"`
+	SessionStart time.Time `json:"sessionStart"`
+	UserAgent    string    `json:"userAgent"`
+	IP           string    `json:"ip"`
+	// whether it is the session of the request
+	Current bool `json:"current"`
+}
+
+// Handle returns the ID the session is listed with, see `SessionInfo`.
+func (sid SessionID) Handle() string {
+	sum := sha256.Sum256([]byte(sid))
+	return hex.EncodeToString(sum[:16])
+}
+
+// IndexSession adds a session to the sessions of the user with `owner` as ID, see `GetSessions`.
+func (rs *RedisStore) IndexSession(owner string, sid SessionID, state *State) error {
+	j, err := json.Marshal(&SessionInfo{
+		ID:           sid.Handle(),
+		SessionStart: state.SessionStart,
+		UserAgent:    state.UserAgent,
+		IP:           state.IP,
+	})
+	if err != nil {
+		return err
+	}
+	return rs.Client.HSet(sessionsKey(owner), string(sid), j).Err()
+}
+
+// UnindexSession removes a session from the sessions of a user, once it ended.
+func (rs *RedisStore) UnindexSession(owner string, sid SessionID) error {
+	return rs.Client.HDel(sessionsKey(owner), string(sid)).Err()
+}
+
+// GetSessions returns the active sessions of a user, earliest first, marking `current` as such;
+// sessions that expired are removed from the index.
+func (rs *RedisStore) GetSessions(owner string, current SessionID) ([]*SessionInfo, error) {
+	sids, infos, err := rs.getSessions(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []*SessionInfo{}
+	for i, info := range infos {
+		info.Current = sids[i] == current
+		sessions = append(sessions, info)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].SessionStart.Before(sessions[j].SessionStart)
+	})
+	return sessions, nil
+}
+
+// RevokeSession ends the session of a user listed with `id`, see `SessionInfo`;
+// it returns ErrStateNotFound if the user has no such active session.
+func (rs *RedisStore) RevokeSession(owner, id string) error {
+	sids, _, err := rs.getSessions(owner)
+	if err != nil {
+		return err
+	}
+
+	for _, sid := range sids {
+		if sid.Handle() == id {
+			return rs.revoke(owner, sid)
+		}
+	}
+	return ErrStateNotFound
+}
+
+// RevokeSessions ends every active session of a user but `keep`, if given, and returns how many it ended.
+func (rs *RedisStore) RevokeSessions(owner string, keep SessionID) (int, error) {
+	sids, _, err := rs.getSessions(owner)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, sid := range sids {
+		if sid == keep {
+			continue
+		}
+		if err := rs.revoke(owner, sid); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// getSessions returns the session IDs and infos of the active sessions of a user,
+// removing the sessions that expired from the index.
+func (rs *RedisStore) getSessions(owner string) ([]SessionID, []*SessionInfo, error) {
+	all, err := rs.Client.HGetAll(sessionsKey(owner)).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// whether every session still exists, in one round trip
+	exists := map[string]*redis.IntCmd{}
+	if _, err := rs.Client.Pipelined(func(pipe redis.Pipeliner) error {
+		for sid := range all {
+			exists[sid] = pipe.Exists(SessionID(sid).getRedisKey())
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var sids []SessionID
+	var infos []*SessionInfo
+	var expired []string
+	for sid, j := range all {
+		if exists[sid].Val() == 0 {
+			expired = append(expired, sid)
+			continue
+		}
+		info := &SessionInfo{}
+		if err := json.Unmarshal([]byte(j), info); err != nil {
+			return nil, nil, err
+		}
+		sids = append(sids, SessionID(sid))
+		infos = append(infos, info)
+	}
+
+	if len(expired) != 0 {
+		rs.Client.HDel(sessionsKey(owner), expired...)
+	}
+	return sids, infos, nil
+}
+
+// revoke ends a session of a user and removes it from the index.
+func (rs *RedisStore) revoke(owner string, sid SessionID) error {
+	_, err := rs.Client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(sid.getRedisKey())
+		pipe.HDel(sessionsKey(owner), string(sid))
+		return nil
+	})
+	return err
+}
+
+// sessionsKey returns the redis key to use for the index of the sessions of a user.
+func sessionsKey(owner string) string {
+	return "sessions:" + owner
+}
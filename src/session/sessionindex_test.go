@@ -0,0 +1,67 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedisStore_Sessions(t *testing.T) {
+	rs, owner := newTestRedisStore(t)
+	t.Cleanup(func() { rs.Client.Del(sessionsKey(owner)) })
+
+	keys := NewSigningKeys("key")
+	var sids []SessionID
+	for i, agent := range []string{"laptop", "phone", "lab"} {
+		sid, err := keys.NewSessionID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		state := &State{SessionStart: time.Now().Add(time.Duration(i) * time.Minute), UserAgent: agent, IP: "127.0.0.1"}
+		if err := rs.Save(sid, state); err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.IndexSession(owner, sid, state); err != nil {
+			t.Fatal(err)
+		}
+		sids = append(sids, sid)
+	}
+	t.Cleanup(func() {
+		for _, sid := range sids {
+			rs.Delete(sid)
+		}
+	})
+
+	sessions, err := rs.GetSessions(owner, sids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 3 || sessions[0].UserAgent != "laptop" || !sessions[1].Current || sessions[0].Current {
+		t.Fatalf("unexpected sessions %+v", sessions)
+	}
+	if sessions[0].ID != sids[0].Handle() || sessions[0].ID == string(sids[0]) {
+		t.Errorf("expected sessions listed by handle, got %v", sessions[0].ID)
+	}
+
+	// an expired session is no longer listed
+	rs.Delete(sids[2])
+	if sessions, _ := rs.GetSessions(owner, sids[1]); len(sessions) != 2 {
+		t.Errorf("expected 2 sessions once one expired, got %v", len(sessions))
+	}
+
+	if err := rs.RevokeSession(owner, sids[0].Handle()); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.Get(sids[0], &State{}); err == nil {
+		t.Errorf("expected a revoked session to be gone")
+	}
+	if err := rs.RevokeSession(owner, sids[0].Handle()); err != ErrStateNotFound {
+		t.Errorf("expected %v revoking a session twice, got %v", ErrStateNotFound, err)
+	}
+
+	if revoked, err := rs.RevokeSessions(owner, sids[1]); err != nil || revoked != 0 {
+		t.Errorf("expected to keep the only session left, got %v, %v", revoked, err)
+	}
+	if revoked, err := rs.RevokeSessions(owner, InvalidSessionID); err != nil || revoked != 1 {
+		t.Errorf("expected to revoke the last session, got %v, %v", revoked, err)
+	}
+}
@@ -4,15 +4,17 @@ import (
 	"time"
 )
 
-// State tracks when the session was started and who
-// started the session.
+// State tracks when the session was started, who
+// started the session and from which device.
 type State struct {
 	SessionStart time.Time   `json:"sessionStart"`
 	Interface    interface{} `json:"state"`
+	UserAgent    string      `json:"userAgent,omitempty"`
+	IP           string      `json:"ip,omitempty"`
 }
 
 // NewSessionState creates a new session state given the start time
 // and user
 func NewSessionState(sessionStart time.Time, i interface{}) *State {
-	return &State{sessionStart, i}
+	return &State{SessionStart: sessionStart, Interface: i}
 }